@@ -112,7 +112,16 @@ func (c *Callback) Do(t *Task) error {
 	return nil
 }
 
+// Send 同步发送，非 200 时返回错误；不经过监听队列，也不重试，可在未 Listen 时使用
+func (c *Callback) Send(ctx context.Context, t *Task) error {
+	return c.send(ctx, t)
+}
+
 func (c *Callback) do(t *Task) error {
+	return c.send(c.ctx, t)
+}
+
+func (c *Callback) send(ctx context.Context, t *Task) error {
 
 	if t == nil {
 		return nil
@@ -129,7 +138,7 @@ func (c *Callback) do(t *Task) error {
 		body = strings.NewReader(t.Body)
 	}
 
-	req, err := http.NewRequestWithContext(c.ctx, http.MethodPost, t.URL, body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, body)
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,7 @@ package callback
 
 import (
 	"context"
+	"github.com/jack0829/letsgo/http/breaker"
 	"github.com/jack0829/letsgo/queue"
 	"io"
 	"net/http"
@@ -10,8 +11,9 @@ import (
 
 type (
 	option struct {
-		c []callbackOption
-		q []queue.Option[*Task]
+		c       []callbackOption
+		q       []queue.Option[*Task]
+		breaker *breaker.Breaker
 	}
 	callbackOption func(c *Callback)
 	Option         func(o *option)
@@ -28,6 +30,12 @@ func (o *option) New(ctx context.Context) *Callback {
 		op(c)
 	}
 
+	if o.breaker != nil {
+		cl := *c.c()
+		cl.Transport = o.breaker.RoundTripper(cl.Transport)
+		c.client = &cl
+	}
+
 	return c
 }
 
@@ -39,6 +47,13 @@ func WithClient(client *http.Client) Option {
 	}
 }
 
+// WithBreaker 按回调地址的 host 熔断，熔断期间的任务直接失败并进入重试
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(o *option) {
+		o.breaker = b
+	}
+}
+
 func WithSaver(s Saver) Option {
 	return func(o *option) {
 		o.q = append(
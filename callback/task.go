@@ -31,3 +31,20 @@ func NewTask(url string, ops ...TaskOption) *Task {
 	}
 	return t
 }
+
+// ReadBody 将 WithBody 传入的 Reader 读入 Body，便于序列化保存
+func (t *Task) ReadBody() error {
+
+	if t.body == nil {
+		return nil
+	}
+
+	b, err := io.ReadAll(t.body)
+	if err != nil {
+		return err
+	}
+
+	t.Body = string(b)
+	t.body = nil
+	return nil
+}
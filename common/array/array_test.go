@@ -0,0 +1,100 @@
+package array
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func expect(t *testing.T, name string, got, want any) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%s: got %v want %v", name, got, want)
+	}
+}
+
+func TestReduce(t *testing.T) {
+
+	arr := []int{1, 2, 3, 4}
+
+	expect(t, "FlatMap", FlatMap(arr, func(v, _ int) []int { return []int{v, v} }), []int{1, 1, 2, 2, 3, 3, 4, 4})
+	expect(t, "Reduce", Reduce(arr, func(v, _ int) []int { return []int{v, v} }), []int{1, 1, 2, 2, 3, 3, 4, 4})
+	expect(t, "FoldFirst", FoldFirst(arr, func(acc, v, _ int) int { return acc * v }), 24)
+	expect(t, "FoldFirst empty", FoldFirst(nil, func(acc, v, _ int) int { return acc + v }), 0)
+	expect(t, "Fold", Fold(arr, "", func(acc string, v, _ int) string { return acc + strconv.Itoa(v) }), "1234")
+	expect(t, "Sum", Sum([]float64{0.5, 1.5}), 2)
+	expect(t, "SumBy", SumBy([]string{"a", "bc"}, func(s string) int { return len(s) }), 3)
+}
+
+func TestCollection(t *testing.T) {
+
+	arr := []int{1, 2, 3, 4, 5, 6, 7}
+	odd := func(v, _ int) bool { return v%2 == 1 }
+
+	expect(t, "Map", Map(arr[:3], func(v, i int) string { return strconv.Itoa(v * i) }), []string{"0", "2", "6"})
+	expect(t, "Filter", Filter(arr, odd), []int{1, 3, 5, 7})
+
+	yes, no := Partition(arr, odd)
+	expect(t, "Partition", [][]int{yes, no}, [][]int{{1, 3, 5, 7}, {2, 4, 6}})
+
+	expect(t, "Chunk", Chunk(arr, 3), [][]int{{1, 2, 3}, {4, 5, 6}, {7}})
+	expect(t, "Chunk 0", Chunk(arr, 0), [][]int(nil))
+
+	g := GroupBy(arr, func(v int) int { return v % 3 })
+	expect(t, "GroupBy", [][]int{g[0], g[1], g[2]}, [][]int{{3, 6}, {1, 4, 7}, {2, 5}})
+
+	words := []string{"go", "gin", "zap", "go"}
+	expect(t, "KeyBy", len(KeyBy(words, func(s string) string { return s })), 3)
+	expect(t, "Uniq", Uniq(words), []string{"go", "gin", "zap"})
+	expect(t, "UniqBy", UniqBy(words, func(s string) int { return len(s) }), []string{"go", "gin"})
+	expect(t, "Diff", Diff(arr, []int{2, 4, 8}), []int{1, 3, 5, 6, 7})
+	expect(t, "Zip", Zip(words, arr), []Pair[string, int]{{"go", 1}, {"gin", 2}, {"zap", 3}, {"go", 4}})
+}
+
+func TestParallel(t *testing.T) {
+
+	ctx := context.Background()
+	arr := make([]int, 100)
+	for i := range arr {
+		arr[i] = i
+	}
+
+	var cur, peak int32
+	r, err := ParallelMap(ctx, arr, 4, func(_ context.Context, v, _ int) (int, error) {
+		n := atomic.AddInt32(&cur, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&cur, -1)
+		return v * v, nil
+	})
+	if err != nil || r[9] != 81 || len(r) != 100 {
+		t.Fatalf("ParallelMap %v %v", r, err)
+	}
+	if peak > 4 {
+		t.Fatalf("peak %d", peak)
+	}
+
+	even, err := ParallelFilter(ctx, arr[:10], 3, func(_ context.Context, v, _ int) (bool, error) {
+		return v%2 == 0, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, "ParallelFilter", even, []int{0, 2, 4, 6, 8})
+
+	err = ParallelEach(ctx, arr, 8, func(_ context.Context, v, _ int) error {
+		if v == 50 {
+			return fmt.Errorf("fail %d", v)
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "fail 50" {
+		t.Fatalf("ParallelEach %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package array
+
+// GroupBy 按 key 分组，组内保持原顺序
+func GroupBy[T any, K comparable](arr []T, fn func(T) K) map[K][]T {
+	r := make(map[K][]T)
+	for _, v := range arr {
+		k := fn(v)
+		r[k] = append(r[k], v)
+	}
+	return r
+}
+
+// KeyBy 按 key 建立索引，key 重复时保留最后一个
+func KeyBy[T any, K comparable](arr []T, fn func(T) K) map[K]T {
+	r := make(map[K]T, len(arr))
+	for _, v := range arr {
+		r[fn(v)] = v
+	}
+	return r
+}
+
+// Chunk 按 size 切分，最后一组可能不足 size；size < 1 时返回 nil
+// 各组与 arr 共享底层数组
+func Chunk[T any](arr []T, size int) (r [][]T) {
+	if size < 1 {
+		return
+	}
+	for i := 0; i < len(arr); i += size {
+		end := min(i+size, len(arr))
+		r = append(r, arr[i:end:end])
+	}
+	return
+}
+
+type Pair[A, B any] struct {
+	First  A
+	Second B
+}
+
+// Zip 按位置组合，长度取较短者
+func Zip[A, B any](a []A, b []B) []Pair[A, B] {
+	r := make([]Pair[A, B], min(len(a), len(b)))
+	for i := range r {
+		r[i] = Pair[A, B]{a[i], b[i]}
+	}
+	return r
+}
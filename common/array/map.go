@@ -0,0 +1,32 @@
+package array
+
+// Map 逐个映射
+func Map[T1, T2 any](arr []T1, fn func(T1, int) T2) []T2 {
+	r := make([]T2, len(arr))
+	for i, v := range arr {
+		r[i] = fn(v, i)
+	}
+	return r
+}
+
+// Filter 保留 fn 返回 true 的元素
+func Filter[T any](arr []T, fn func(T, int) bool) (r []T) {
+	for i, v := range arr {
+		if fn(v, i) {
+			r = append(r, v)
+		}
+	}
+	return
+}
+
+// Partition 按 fn 结果分成两组
+func Partition[T any](arr []T, fn func(T, int) bool) (yes, no []T) {
+	for i, v := range arr {
+		if fn(v, i) {
+			yes = append(yes, v)
+		} else {
+			no = append(no, v)
+		}
+	}
+	return
+}
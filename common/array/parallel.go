@@ -0,0 +1,51 @@
+package array
+
+import (
+	"context"
+	"github.com/jack0829/letsgo/common/async"
+)
+
+// ParallelMap 以最多 size 个并发逐个映射，结果保持原顺序
+// 任一映射出错即取消其余映射并返回该错误
+func ParallelMap[T1, T2 any](ctx context.Context, arr []T1, size int, fn func(ctx context.Context, v T1, i int) (T2, error)) ([]T2, error) {
+
+	r := make([]T2, len(arr))
+	p := async.NewPool(ctx, size)
+	for i, v := range arr {
+		if !p.Go(func(ctx context.Context) (err error) {
+			r[i], err = fn(ctx, v, i)
+			return
+		}) {
+			break
+		}
+	}
+
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ParallelFilter 以最多 size 个并发判断，结果保持原顺序
+func ParallelFilter[T any](ctx context.Context, arr []T, size int, fn func(ctx context.Context, v T, i int) (bool, error)) ([]T, error) {
+
+	keep, err := ParallelMap(ctx, arr, size, fn)
+	if err != nil {
+		return nil, err
+	}
+
+	return Filter(arr, func(_ T, i int) bool {
+		return keep[i]
+	}), nil
+}
+
+// ParallelEach 以最多 size 个并发处理每个元素
+func ParallelEach[T any](ctx context.Context, arr []T, size int, fn func(ctx context.Context, v T, i int) error) error {
+	_, err := ParallelMap(ctx, arr, size, func(ctx context.Context, v T, i int) (struct{}, error) {
+		return struct{}{}, fn(ctx, v, i)
+	})
+	return err
+}
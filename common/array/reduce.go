@@ -1,8 +1,54 @@
 package array
 
-func Reduce[T1, T2 any](arr []T1, fn func(T1, int) []T2) (r []T2) {
+import (
+	"github.com/jack0829/letsgo/common/numeric"
+	"github.com/jack0829/letsgo/common/types"
+)
+
+// FlatMap 每个元素映射为多个结果后拼接
+func FlatMap[T1, T2 any](arr []T1, fn func(T1, int) []T2) (r []T2) {
 	for i, v := range arr {
 		r = append(r, fn(v, i)...)
 	}
 	return
 }
+
+// Reduce 每个元素映射为多个结果后拼接
+//
+// Deprecated: 使用 FlatMap；依次归并见 Fold、FoldFirst
+func Reduce[T1, T2 any](arr []T1, fn func(T1, int) []T2) []T2 {
+	return FlatMap(arr, fn)
+}
+
+// FoldFirst 以第一个元素为初始值依次归并，arr 为空时返回零值
+func FoldFirst[T any](arr []T, fn func(acc, v T, i int) T) (r T) {
+	if len(arr) == 0 {
+		return
+	}
+	r = arr[0]
+	for i := 1; i < len(arr); i++ {
+		r = fn(r, arr[i], i)
+	}
+	return
+}
+
+// Fold 从 init 开始依次归并
+func Fold[T, R any](arr []T, init R, fn func(acc R, v T, i int) R) R {
+	for i, v := range arr {
+		init = fn(init, v, i)
+	}
+	return init
+}
+
+// Sum 求和
+func Sum[T types.Number](arr []T) T {
+	return numeric.Sum(arr...)
+}
+
+// SumBy 按 fn 取值求和
+func SumBy[T any, N types.Number](arr []T, fn func(T) N) (r N) {
+	for _, v := range arr {
+		r += fn(v)
+	}
+	return
+}
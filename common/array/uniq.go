@@ -0,0 +1,34 @@
+package array
+
+// Uniq 去重，保留首次出现的顺序
+func Uniq[T comparable](arr []T) []T {
+	return UniqBy(arr, func(v T) T { return v })
+}
+
+// UniqBy 按 key 去重，保留首次出现的元素
+func UniqBy[T any, K comparable](arr []T, fn func(T) K) (r []T) {
+	seen := make(map[K]struct{}, len(arr))
+	for _, v := range arr {
+		k := fn(v)
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		r = append(r, v)
+	}
+	return
+}
+
+// Diff 在 a 中但不在 b 中的元素，保持 a 的顺序
+func Diff[T comparable](a, b []T) (r []T) {
+	exclude := make(map[T]struct{}, len(b))
+	for _, v := range b {
+		exclude[v] = struct{}{}
+	}
+	for _, v := range a {
+		if _, ok := exclude[v]; !ok {
+			r = append(r, v)
+		}
+	}
+	return
+}
@@ -2,13 +2,20 @@ package async
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/common/limiter"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 func TestPipe(t *testing.T) {
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 	t1 := time.NewTicker(time.Second)
 	t2 := make(chan time.Time)
 	defer close(t2)
@@ -35,7 +42,8 @@ LOOP:
 }
 func TestMultiRead(t *testing.T) {
 
-	ctx, _ := context.WithTimeout(context.Background(), time.Second*5)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
 	t1 := time.NewTicker(time.Second)
 	t2 := time.NewTicker(time.Millisecond * 400)
 
@@ -57,3 +65,404 @@ LOOP:
 	}
 
 }
+
+func testSource(n int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 0; i < n; i++ {
+			ch <- i
+		}
+	}()
+	return ch
+}
+
+func TestParallelMap(t *testing.T) {
+
+	ctx := context.Background()
+	square := func(_ context.Context, v int) (int, error) {
+		time.Sleep(time.Millisecond * time.Duration(10-v%10))
+		return v * v, nil
+	}
+
+	out, wait := ParallelMap(ctx, testSource(50), 8, true, square)
+	var i int
+	for v := range out {
+		if v != i*i {
+			t.Fatalf("ordered: got %d at %d", v, i)
+		}
+		i++
+	}
+	if err := wait(); err != nil || i != 50 {
+		t.Fatalf("ordered: %d %v", i, err)
+	}
+
+	out, wait = ParallelMap(ctx, testSource(50), 8, false, square)
+	var sum int
+	for v := range out {
+		sum += v
+	}
+	if err := wait(); err != nil || sum != 40425 {
+		t.Fatalf("unordered: %d %v", sum, err)
+	}
+}
+
+// 读到一半取消 ctx，wait 返回 context.Canceled
+func TestParallelMapCancel(t *testing.T) {
+
+	for _, ordered := range []bool{true, false} {
+		t.Run(fmt.Sprint("ordered=", ordered), func(t *testing.T) {
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, wait := ParallelMap(ctx, testSource(1000), 4, ordered, func(_ context.Context, v int) (int, error) {
+				return v, nil
+			})
+
+			var cnt int
+			for range out {
+				if cnt++; cnt == 5 {
+					cancel()
+				}
+			}
+
+			if err := wait(); !errors.Is(err, context.Canceled) {
+				t.Fatalf("got %d, err %v", cnt, err)
+			}
+			if cnt >= 1000 {
+				t.Error("output not truncated")
+			}
+		})
+	}
+}
+
+func TestParallelMapError(t *testing.T) {
+
+	out, wait := Map(context.Background(), testSource(100), func(_ context.Context, v int) (int, error) {
+		if v == 10 {
+			panic("boom")
+		}
+		return v, nil
+	})
+
+	var cnt int
+	for range out {
+		cnt++
+	}
+
+	err := wait()
+	if _, ok := err.(*PanicError); !ok {
+		t.Fatalf("expect panic error, got %v", err)
+	}
+	if cnt > 10 {
+		t.Errorf("outputs before failure: %d", cnt)
+	}
+}
+
+func TestPool(t *testing.T) {
+
+	var (
+		mutex        sync.Mutex
+		running, max int
+	)
+
+	p := NewPool(context.Background(), 3)
+	for i := 0; i < 20; i++ {
+		p.Go(func(ctx context.Context) error {
+			mutex.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mutex.Unlock()
+			time.Sleep(time.Millisecond * 5)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		})
+	}
+	if err := p.Wait(); err != nil || max > 3 {
+		t.Fatalf("max %d, err %v", max, err)
+	}
+
+	fail := errors.New("fail")
+	p = NewPool(context.Background(), 2)
+	p.Go(func(ctx context.Context) error { return fail })
+	p.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err := p.Wait(); err != fail {
+		t.Fatalf("expect first error, got %v", err)
+	}
+	if p.Go(func(ctx context.Context) error { return nil }) {
+		t.Error("canceled pool accepted task")
+	}
+}
+
+func TestFanOut(t *testing.T) {
+
+	outs := FanOut(context.Background(), testSource(30), 3)
+	got := MultiRead(context.Background(), outs...)
+
+	var cnt int
+	for range got {
+		cnt++
+	}
+	if cnt != 30 {
+		t.Fatalf("got %d", cnt)
+	}
+}
+
+func TestBatch(t *testing.T) {
+
+	ctx := context.Background()
+
+	var sizes []int
+	for b := range Batch(ctx, testSource(10), 4, time.Second) {
+		sizes = append(sizes, len(b))
+	}
+	if fmt.Sprint(sizes) != "[4 4 2]" {
+		t.Fatalf("by size: %v", sizes)
+	}
+
+	in := make(chan int)
+	out := Batch(ctx, in, 100, time.Millisecond*20)
+	go func() {
+		defer close(in)
+		in <- 1
+		in <- 2
+		time.Sleep(time.Millisecond * 60)
+		in <- 3
+	}()
+
+	sizes = nil
+	for b := range out {
+		sizes = append(sizes, len(b))
+	}
+	if fmt.Sprint(sizes) != "[2 1]" {
+		t.Fatalf("by wait: %v", sizes)
+	}
+}
+
+func TestDebounce(t *testing.T) {
+
+	in := make(chan int)
+	out := Debounce(context.Background(), in, time.Millisecond*30)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 5; i++ {
+			in <- i
+		}
+		time.Sleep(time.Millisecond * 60)
+		in <- 6
+		in <- 7
+	}()
+
+	var got []int
+	for v := range out {
+		got = append(got, v)
+	}
+	if fmt.Sprint(got) != "[5 7]" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+
+	l := limiter.New(time.Millisecond*20, 1, 1)
+	defer l.Stop()
+
+	start := time.Now()
+	var cnt int
+	for range Throttle(context.Background(), testSource(5), l) {
+		cnt++
+	}
+	if d := time.Since(start); cnt != 5 || d < time.Millisecond*70 {
+		t.Fatalf("%d in %s", cnt, d)
+	}
+}
+
+func TestTee(t *testing.T) {
+
+	outs := Tee(context.Background(), testSource(10), 3, 2)
+
+	wg := sync.WaitGroup{}
+	sums := make([]int, len(outs))
+	for i, out := range outs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range out {
+				sums[i] += v
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, sum := range sums {
+		if sum != 45 {
+			t.Errorf("consumer %d: %d", i, sum)
+		}
+	}
+}
+
+func TestFuture(t *testing.T) {
+
+	ctx := context.Background()
+	delay := func(d time.Duration, v int, err error) *Future[int] {
+		return Go(func() (int, error) {
+			time.Sleep(d)
+			return v, err
+		})
+	}
+	fail := errors.New("fail")
+
+	list, err := All(ctx, delay(20*time.Millisecond, 1, nil), delay(0, 2, nil))
+	if err != nil || fmt.Sprint(list) != "[1 2]" {
+		t.Fatalf("All: %v %v", list, err)
+	}
+
+	if _, err = All(ctx, delay(0, 1, nil), delay(0, 0, fail)); err != fail {
+		t.Fatalf("All error: %v", err)
+	}
+
+	v, err := Any(ctx, delay(0, 0, fail), delay(20*time.Millisecond, 2, nil))
+	if err != nil || v != 2 {
+		t.Fatalf("Any: %d %v", v, err)
+	}
+
+	if _, err = Any(ctx, delay(0, 0, fail), delay(0, 0, fail)); !errors.Is(err, fail) {
+		t.Fatalf("Any error: %v", err)
+	}
+
+	if v, err = Race(ctx, delay(0, 0, fail), delay(50*time.Millisecond, 2, nil)); err != fail {
+		t.Fatalf("Race: %d %v", v, err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err = delay(time.Second, 1, nil).Await(timeout); err != context.DeadlineExceeded {
+		t.Fatalf("Await: %v", err)
+	}
+
+	f, resolve := NewPromise[int]()
+	resolve(1, nil)
+	resolve(2, nil)
+	if v, _ = f.Await(ctx); v != 1 {
+		t.Fatalf("Promise: %d", v)
+	}
+}
+
+// TestRaceLeak Race、Any 返回后，等待其余 Future 的协程应退出
+func TestRaceLeak(t *testing.T) {
+
+	ctx := context.Background()
+	base := runtime.NumGoroutine()
+
+	for i := 0; i < 100; i++ {
+		done, resolve := NewPromise[int]()
+		resolve(i, nil)
+		never, _ := NewPromise[int]()
+		if v, err := Race(ctx, done, never); err != nil || v != i {
+			t.Fatalf("Race: %d %v", v, err)
+		}
+		if v, err := Any(ctx, never, done); err != nil || v != i {
+			t.Fatalf("Any: %d %v", v, err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines: %d -> %d", base, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFlight(t *testing.T) {
+
+	var (
+		g     Flight[string, int]
+		calls int32
+		wg    sync.WaitGroup
+	)
+
+	start := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			v, err, _ := g.Do("token", func() (int, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(time.Millisecond * 50)
+				return 42, nil
+			})
+			if v != 42 || err != nil {
+				t.Errorf("%d %v", v, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("calls %d", calls)
+	}
+
+	// 结束后再次调用会重新执行
+	g.Do("token", func() (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 0, nil
+	})
+	if calls != 2 {
+		t.Fatalf("calls %d", calls)
+	}
+}
+
+func TestFlightShared(t *testing.T) {
+
+	const n = 8
+	var (
+		g       Flight[string, int]
+		wg      sync.WaitGroup
+		shared  int32
+		release = make(chan struct{})
+	)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, ok := g.Do("token", func() (int, error) {
+				<-release
+				return 42, nil
+			})
+			if ok {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+
+	// 等所有调用方都加入后再返回
+	for {
+		g.mutex.Lock()
+		c := g.calls["token"]
+		joined := c != nil && c.dups.Load() == n-1
+		g.mutex.Unlock()
+		if joined {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if shared != n {
+		t.Fatalf("shared %d", shared)
+	}
+}
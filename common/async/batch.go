@@ -0,0 +1,83 @@
+package async
+
+import (
+	"context"
+	"time"
+)
+
+// Batch 将 in 中的数据分批输出，满 size 条或首条数据等待超过 wait 时输出一批
+// in 关闭时输出剩余数据
+func Batch[T any](
+	ctx context.Context,
+	in <-chan T,
+	size int,
+	wait time.Duration,
+) <-chan []T {
+
+	if size < 1 {
+		size = 1
+	}
+
+	out := make(chan []T)
+
+	go func() {
+
+		defer close(out)
+
+		var (
+			batch []T
+			timer *time.Timer
+			tc    <-chan time.Time // 当前批次未开始时为 nil
+		)
+
+		flush := func() bool {
+			if timer != nil {
+				timer.Stop()
+				tc = nil
+			}
+			if len(batch) < 1 {
+				return true
+			}
+			select {
+			case out <- batch:
+				batch = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tc:
+				if !flush() {
+					return
+				}
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				batch = append(batch, v)
+				if len(batch) >= size {
+					if !flush() {
+						return
+					}
+					continue
+				}
+				if tc == nil && wait > 0 {
+					if timer == nil {
+						timer = time.NewTimer(wait)
+					} else {
+						timer.Reset(wait)
+					}
+					tc = timer.C
+				}
+			}
+		}
+	}()
+
+	return out
+}
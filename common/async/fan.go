@@ -0,0 +1,26 @@
+package async
+
+import (
+	"context"
+)
+
+// FanOut 将 in 分发给 n 个输出，每条数据只被其中一个消费者读到（谁空闲谁读）
+// 反向汇聚见 MultiRead
+func FanOut[T any](ctx context.Context, in <-chan T, n int) []<-chan T {
+
+	if n < 1 {
+		n = 1
+	}
+
+	list := make([]<-chan T, n)
+	for i := range list {
+		out := make(chan T)
+		list[i] = out
+		go func() {
+			defer close(out)
+			<-Pipe(ctx, out, in)
+		}()
+	}
+
+	return list
+}
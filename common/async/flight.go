@@ -0,0 +1,65 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Flight 按 key 合并并发调用：同一 key 同时只执行一次，期间的调用方共享其结果
+// 零值可用
+type Flight[K comparable, V any] struct {
+	mutex sync.Mutex
+	calls map[K]*flightCall[V]
+}
+
+type flightCall[V any] struct {
+	f    *Future[V]
+	dups atomic.Int32 // 等待方在锁外读取
+}
+
+// Do 执行或等待 key 对应的调用，shared 表示结果被多个调用方共享
+func (g *Flight[K, V]) Do(key K, fn func() (V, error)) (v V, err error, shared bool) {
+	return g.DoCtx(context.Background(), key, fn)
+}
+
+// DoCtx 同 Do，ctx 结束时调用方提前返回，fn 仍会执行完并供其他调用方使用
+func (g *Flight[K, V]) DoCtx(ctx context.Context, key K, fn func() (V, error)) (v V, err error, shared bool) {
+
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*flightCall[V])
+	}
+
+	c, ok := g.calls[key]
+	if ok {
+		c.dups.Add(1)
+	} else {
+		c = &flightCall[V]{}
+		g.calls[key] = c
+		c.f = Go(func() (V, error) {
+			defer func() {
+				g.mutex.Lock()
+				if g.calls[key] == c {
+					delete(g.calls, key)
+				}
+				g.mutex.Unlock()
+			}()
+			return fn()
+		})
+	}
+	g.mutex.Unlock()
+
+	if v, err = c.f.Await(ctx); err != nil && ctx.Err() != nil {
+		return
+	}
+
+	return v, err, c.dups.Load() > 0
+}
+
+// Forget 忘记 key 对应的进行中调用，之后的调用会重新执行
+func (g *Flight[K, V]) Forget(key K) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.calls, key)
+}
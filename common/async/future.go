@@ -0,0 +1,170 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Future 异步计算的结果
+type Future[T any] struct {
+	done chan struct{}
+	once sync.Once
+	val  T
+	err  error
+}
+
+// Go 异步执行 fn，panic 转换为 *PanicError
+func Go[T any](fn func() (T, error)) *Future[T] {
+
+	f, resolve := NewPromise[T]()
+
+	go func() {
+		var v T
+		err := Recover(func() (err error) {
+			v, err = fn()
+			return
+		})
+		resolve(v, err)
+	}()
+
+	return f
+}
+
+// NewPromise 由调用方完成的 Future，resolve 只有第一次调用生效
+func NewPromise[T any]() (*Future[T], func(v T, err error)) {
+
+	f := &Future[T]{
+		done: make(chan struct{}),
+	}
+
+	return f, func(v T, err error) {
+		f.once.Do(func() {
+			f.val, f.err = v, err
+			close(f.done)
+		})
+	}
+}
+
+// Done 完成时关闭
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Await 等待结果，ctx 结束时返回 ctx.Err()，不影响计算本身
+func (f *Future[T]) Await(ctx context.Context) (v T, err error) {
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+}
+
+// All 等待全部完成，按顺序返回结果；任一失败即返回该错误
+func All[T any](ctx context.Context, fs ...*Future[T]) ([]T, error) {
+
+	list := make([]T, len(fs))
+	for i, f := range fs {
+		v, err := f.Await(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+
+	return list, nil
+}
+
+// Any 返回第一个成功的结果，全部失败时返回合并后的错误
+func Any[T any](ctx context.Context, fs ...*Future[T]) (v T, err error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errs []error
+	for f := range settle(ctx, fs) {
+		if f == nil {
+			err = ctx.Err()
+			return
+		}
+		if f.err == nil {
+			return f.val, nil
+		}
+		errs = append(errs, f.err)
+	}
+
+	if len(errs) < 1 {
+		err = errNoFuture
+		return
+	}
+
+	err = errors.Join(errs...)
+	return
+}
+
+// Race 返回第一个完成的结果，无论成败
+func Race[T any](ctx context.Context, fs ...*Future[T]) (v T, err error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for f := range settle(ctx, fs) {
+		if f == nil {
+			err = ctx.Err()
+			return
+		}
+		return f.val, f.err
+	}
+
+	err = errNoFuture
+	return
+}
+
+var errNoFuture = fmt.Errorf("async: 没有 Future")
+
+// settle 按完成顺序输出；ctx 结束时输出 nil，剩余协程随之退出
+// 读取方不再读取时须取消 ctx，否则协程阻塞在发送上
+func settle[T any](ctx context.Context, fs []*Future[T]) <-chan *Future[T] {
+
+	out := make(chan *Future[T])
+
+	go func() {
+
+		defer close(out)
+
+		ch := make(chan *Future[T], len(fs))
+		stop := make(chan struct{})
+		defer close(stop)
+
+		for _, f := range fs {
+			go func() {
+				select {
+				case <-f.done:
+					ch <- f
+				case <-stop:
+				}
+			}()
+		}
+
+		for range fs {
+			var f *Future[T]
+			select {
+			case f = <-ch:
+			case <-ctx.Done():
+			}
+			select {
+			case out <- f:
+			case <-ctx.Done():
+				return
+			}
+			if f == nil {
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,139 @@
+package async
+
+import (
+	"context"
+)
+
+type MapFunc[T, R any] func(ctx context.Context, v T) (R, error)
+
+// Map 逐个转换 in 中的数据，见 ParallelMap
+func Map[T, R any](
+	ctx context.Context,
+	in <-chan T,
+	fn MapFunc[T, R],
+) (<-chan R, func() error) {
+	return ParallelMap(ctx, in, 1, true, fn)
+}
+
+// ParallelMap n 个协程并发转换 in 中的数据，ordered 为 true 时按输入顺序输出
+// 任一转换失败或 panic 即停止，wait 在全部协程结束后返回第一个错误；ctx 取消导致输出不完整时返回 ctx.Err()
+// 调用方需读完输出或取消 ctx，否则 wait 不会返回
+func ParallelMap[T, R any](
+	ctx context.Context,
+	in <-chan T,
+	n int,
+	ordered bool,
+	fn MapFunc[T, R],
+) (_ <-chan R, wait func() error) {
+
+	if n < 1 {
+		n = 1
+	}
+
+	var (
+		pool = NewPool(ctx, n)
+		out  = make(chan R)
+		done = make(chan struct{})
+		err  error
+	)
+	parent := ctx
+	ctx = pool.Context()
+
+	// 转换都成功但 ctx 已取消时，输出可能不完整
+	finish := func() {
+		if err = pool.Wait(); err == nil {
+			err = parent.Err()
+		}
+	}
+
+	wait = func() error {
+		<-done
+		return err
+	}
+
+	if !ordered {
+		go func() {
+			defer close(done)
+			defer close(out)
+			read(ctx, in, func(v T) bool {
+				return pool.Go(func(ctx context.Context) error {
+					r, err := fn(ctx, v)
+					if err != nil {
+						return err
+					}
+					select {
+					case out <- r:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				})
+			})
+			finish()
+		}()
+		return out, wait
+	}
+
+	// 按输入顺序排队，每个结果一个 channel
+	order := make(chan chan R, n)
+	collected := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		read(ctx, in, func(v T) bool {
+			res := make(chan R, 1)
+			if !pool.Go(func(ctx context.Context) error {
+				defer close(res)
+				r, err := fn(ctx, v)
+				if err != nil {
+					return err
+				}
+				res <- r
+				return nil
+			}) {
+				return false
+			}
+			select {
+			case order <- res:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		close(order)
+		<-collected // Wait 会取消 ctx，需等结果全部输出
+		finish()
+	}()
+
+	go func() {
+		defer close(collected)
+		defer close(out)
+		for res := range order {
+			r, ok := <-res
+			if !ok {
+				return // 转换失败，ctx 已取消
+			}
+			select {
+			case out <- r:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, wait
+}
+
+// read 读取 in 直到关闭、ctx 结束或 fn 返回 false
+func read[T any](ctx context.Context, in <-chan T, fn func(v T) bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-in:
+			if !ok || !fn(v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package async
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError 由任务 panic 转换而来的错误
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("async: panic: %v\n%s", e.Value, e.Stack)
+}
+
+// Recover 执行 fn，panic 时转换为 *PanicError
+func Recover(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{
+				Value: r,
+				Stack: debug.Stack(),
+			}
+		}
+	}()
+	return fn()
+}
+
+// Pool 有界并发任务池，任一任务失败即取消 Context
+type Pool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    error
+}
+
+// NewPool size 为最大并发数，<=0 时不限制
+func NewPool(ctx context.Context, size int) *Pool {
+
+	p := &Pool{}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	if size > 0 {
+		p.sem = make(chan struct{}, size)
+	}
+
+	return p
+}
+
+// Context 任务失败或 Wait 返回后即取消
+func (p *Pool) Context() context.Context {
+	return p.ctx
+}
+
+// Go 提交任务，并发已满时阻塞；Context 已取消时不再执行并返回 false
+func (p *Pool) Go(fn func(ctx context.Context) error) bool {
+
+	if p.ctx.Err() != nil {
+		return false
+	}
+
+	if p.sem != nil {
+		select {
+		case <-p.ctx.Done():
+			return false
+		case p.sem <- struct{}{}:
+		}
+	}
+
+	p.wg.Add(1)
+	go func() {
+
+		defer func() {
+			if p.sem != nil {
+				<-p.sem
+			}
+			p.wg.Done()
+		}()
+
+		if err := Recover(func() error {
+			return fn(p.ctx)
+		}); err != nil {
+			p.fail(err)
+		}
+	}()
+
+	return true
+}
+
+func (p *Pool) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// Wait 等待全部任务结束，返回第一个错误
+func (p *Pool) Wait() error {
+	p.wg.Wait()
+	p.cancel()
+	return p.err
+}
@@ -0,0 +1,108 @@
+package async
+
+import (
+	"context"
+	"github.com/jack0829/letsgo/common/limiter"
+	"time"
+)
+
+// Debounce 数据静默 d 之后才输出最后一条，期间的数据被丢弃
+// in 关闭时输出尚未输出的最后一条
+func Debounce[T any](
+	ctx context.Context,
+	in <-chan T,
+	d time.Duration,
+) <-chan T {
+
+	out := make(chan T)
+
+	go func() {
+
+		defer close(out)
+
+		var (
+			last    T
+			pending bool
+			timer   = time.NewTimer(d)
+		)
+		timer.Stop()
+		defer timer.Stop()
+
+		emit := func() bool {
+			if !pending {
+				return true
+			}
+			select {
+			case out <- last:
+				pending = false
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				if !emit() {
+					return
+				}
+			case v, ok := <-in:
+				if !ok {
+					emit()
+					return
+				}
+				last, pending = v, true
+				timer.Reset(d)
+			}
+		}
+	}()
+
+	return out
+}
+
+// Throttle 按限速器的速率输出，每条数据消耗一个令牌，限速器停止时结束
+func Throttle[T any](
+	ctx context.Context,
+	in <-chan T,
+	l *limiter.Limiter,
+) <-chan T {
+
+	out := make(chan T)
+
+	go func() {
+
+		defer close(out)
+
+		for {
+
+			var (
+				v  T
+				ok bool
+			)
+
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok = <-in:
+				if !ok {
+					return
+				}
+			}
+
+			if l.WaitCtx(ctx) != nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- v:
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,47 @@
+package async
+
+import (
+	"context"
+)
+
+// Tee 将 in 广播给 n 个输出，每个输出有 bufSize 的缓冲
+// 任一输出缓冲满时广播阻塞，直到该消费者读取或 ctx 结束
+func Tee[T any](
+	ctx context.Context,
+	in <-chan T,
+	n, bufSize int,
+) []<-chan T {
+
+	if n < 1 {
+		n = 1
+	}
+
+	outs := make([]chan T, n)
+	list := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T, bufSize)
+		list[i] = outs[i]
+	}
+
+	go func() {
+
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		read(ctx, in, func(v T) bool {
+			for _, out := range outs {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		})
+	}()
+
+	return list
+}
@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile 原子写入：先写同目录下的临时文件并 fsync，再替换 path
+// 崩溃时 path 要么是旧内容要么是新内容，不会出现写了一半的文件
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteFileFunc(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteFileFunc 同 WriteFile，内容由 write 写入；write 出错时不替换 path
+func WriteFileFunc(path string, perm os.FileMode, write func(w io.Writer) error) error {
+
+	dir := filepath.Dir(path)
+	if err := MustDir(dir); err != nil {
+		return err
+	}
+
+	fp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := fp.Name()
+
+	// 成功替换后 tmp 已不存在，Remove 无副作用
+	defer os.Remove(tmp)
+
+	if err = write(fp); err != nil {
+		fp.Close()
+		return err
+	}
+	if err = fp.Chmod(perm); err != nil {
+		fp.Close()
+		return err
+	}
+	if err = fp.Sync(); err != nil {
+		fp.Close()
+		return err
+	}
+	if err = fp.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir 持久化目录项，确保 rename 落盘；不支持的平台忽略
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err = d.Sync(); err != nil {
+		return ignoreSyncDirError(err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteFile(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "a.txt")
+
+	if err := WriteFile(path, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// write 失败时保留旧内容
+	err := WriteFileFunc(path, 0600, func(w io.Writer) error {
+		w.Write([]byte("half"))
+		return errors.New("fail")
+	})
+	if err == nil {
+		t.Fatal("expect error")
+	}
+
+	b, _ := os.ReadFile(path)
+	if string(b) != "v1" {
+		t.Fatalf("content %q", b)
+	}
+
+	if s, _ := os.Stat(path); s.Mode().Perm() != 0600 {
+		t.Fatalf("perm %v", s.Mode().Perm())
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("temp file left: %d entries", len(entries))
+	}
+}
+
+func TestLockFile(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "a.lock")
+
+	l, err := LockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = TryLockFile(path); err != ErrLocked {
+		t.Fatalf("expect ErrLocked, got %v", err)
+	}
+
+	if err = l.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	r1, err := RLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := RLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = TryLockFile(path); err != ErrLocked {
+		t.Fatalf("shared: expect ErrLocked, got %v", err)
+	}
+	r1.Unlock()
+	r2.Unlock()
+
+	l, err = TryLockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Unlock()
+}
+
+func TestTempDir(t *testing.T) {
+
+	var tmp string
+	err := WithTempDir("fs-test-*", func(dir string) error {
+		tmp = dir
+		return WriteFile(filepath.Join(dir, "x"), []byte("x"), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = os.Stat(tmp); !os.IsNotExist(err) {
+		t.Fatalf("temp dir not removed: %v", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := Watch(ctx, time.Millisecond*50, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(chan []Event, 10)
+	w.Subscribe("*.yaml", func(events []Event) {
+		got <- events
+	})
+
+	// 连续多次写入只触发一次
+	for i := 0; i < 5; i++ {
+		WriteFile(filepath.Join(dir, "config.yaml"), []byte{byte(i)}, 0644)
+		WriteFile(filepath.Join(dir, "ignore.txt"), []byte{byte(i)}, 0644)
+		time.Sleep(time.Millisecond * 5)
+	}
+
+	select {
+	case events := <-got:
+		if len(events) != 1 || filepath.Base(events[0].Name) != "config.yaml" || events[0].Op&Create == 0 {
+			t.Fatalf("events %+v", events)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("no event")
+	}
+
+	select {
+	case events := <-got:
+		t.Fatalf("unexpected %+v", events)
+	case <-time.After(time.Millisecond * 150):
+	}
+
+	cancel()
+	select {
+	case <-w.Done():
+	case <-time.After(time.Second):
+		t.Fatal("watcher not stopped")
+	}
+}
+
+// 变化持续不断时不会一直等待
+func TestWatchMaxWait(t *testing.T) {
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := Watch(ctx, time.Millisecond*50, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.SetMaxWait(time.Millisecond * 150)
+
+	got := make(chan time.Time, 10)
+	w.Subscribe("", func([]Event) {
+		got <- time.Now()
+	})
+
+	start := time.Now()
+	stop := time.After(time.Millisecond * 600)
+	tick := time.NewTicker(time.Millisecond * 10)
+	defer tick.Stop()
+
+LOOP:
+	for i := 0; ; i++ {
+		select {
+		case <-stop:
+			break LOOP
+		case <-tick.C:
+			os.WriteFile(filepath.Join(dir, "stream.log"), []byte{byte(i)}, 0644)
+		}
+	}
+
+	select {
+	case at := <-got:
+		if d := at.Sub(start); d > time.Millisecond*400 {
+			t.Fatalf("first dispatch after %s", d)
+		}
+	default:
+		t.Fatal("no dispatch during steady writes")
+	}
+}
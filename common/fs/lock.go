@@ -0,0 +1,56 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+)
+
+var ErrLocked = fmt.Errorf("fs: 文件已被其他进程锁定")
+
+// Lock 进程间的建议锁（advisory lock），只约束同样加锁的进程
+type Lock struct {
+	fp *os.File
+}
+
+// LockFile 获取排他锁，已被锁定时阻塞；文件不存在时创建
+func LockFile(path string) (*Lock, error) {
+	return lockFile(path, false, true)
+}
+
+// RLockFile 获取共享锁，已被排他锁定时阻塞
+func RLockFile(path string) (*Lock, error) {
+	return lockFile(path, true, true)
+}
+
+// TryLockFile 获取排他锁，已被锁定时返回 ErrLocked
+func TryLockFile(path string) (*Lock, error) {
+	return lockFile(path, false, false)
+}
+
+func lockFile(path string, shared, block bool) (*Lock, error) {
+
+	fp, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = lock(fp, shared, block); err != nil {
+		fp.Close()
+		return nil, err
+	}
+
+	return &Lock{fp: fp}, nil
+}
+
+// Unlock 释放锁，关闭文件
+func (l *Lock) Unlock() error {
+	if l == nil || l.fp == nil {
+		return nil
+	}
+	err := unlock(l.fp)
+	if e := l.fp.Close(); err == nil {
+		err = e
+	}
+	l.fp = nil
+	return err
+}
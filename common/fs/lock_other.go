@@ -0,0 +1,22 @@
+//go:build !unix && !windows
+
+package fs
+
+import (
+	"errors"
+	"os"
+)
+
+// 其他平台（如 js、wasip1、plan9）不支持文件锁
+func lock(*os.File, bool, bool) error {
+	return errors.ErrUnsupported
+}
+
+func unlock(*os.File) error {
+	return nil
+}
+
+// 不一定支持对目录 fsync，尽力而为
+func ignoreSyncDirError(error) error {
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package fs
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+func lock(fp *os.File, shared, block bool) error {
+
+	var flags uint32
+	if !shared {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	if !block {
+		flags |= windows.LOCKFILE_FAIL_IMMEDIATELY
+	}
+
+	err := windows.LockFileEx(windows.Handle(fp.Fd()), flags, 0, 1, 0, &windows.Overlapped{})
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return ErrLocked
+	}
+	return err
+}
+
+func unlock(fp *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(fp.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
+
+// Windows 不支持对目录 fsync
+func ignoreSyncDirError(error) error {
+	return nil
+}
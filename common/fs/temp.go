@@ -0,0 +1,27 @@
+package fs
+
+import (
+	"os"
+)
+
+// TempDir 在系统临时目录下创建目录，cleanup 删除该目录及其内容
+func TempDir(pattern string) (dir string, cleanup func(), err error) {
+
+	if dir, err = os.MkdirTemp("", pattern); err != nil {
+		return "", func() {}, err
+	}
+
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
+
+// WithTempDir 在临时目录中执行 fn，结束后删除该目录
+func WithTempDir(pattern string, fn func(dir string) error) error {
+
+	dir, cleanup, err := TempDir(pattern)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	return fn(dir)
+}
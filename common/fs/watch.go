@@ -0,0 +1,205 @@
+package fs
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Op = fsnotify.Op
+
+const (
+	Create = fsnotify.Create
+	Write  = fsnotify.Write
+	Remove = fsnotify.Remove
+	Rename = fsnotify.Rename
+	Chmod  = fsnotify.Chmod
+)
+
+// Event 一个文件在防抖窗口内的全部变化，Op 为各次变化的合并
+type Event struct {
+	Name string
+	Op   Op
+}
+
+type subscriber struct {
+	pattern string
+	fn      func(events []Event)
+}
+
+// Watcher 目录监听，事件在 debounce 内没有新变化后才批量分发给订阅者
+// 编辑器保存、原子写入等产生的连续事件只触发一次回调；变化持续不断时最多等待 maxWait 也会分发
+type Watcher struct {
+	w        *fsnotify.Watcher
+	debounce time.Duration
+	maxWait  time.Duration
+	mutex    sync.Mutex
+	subs     map[int]*subscriber
+	nextID   int
+	errs     chan error
+	done     chan struct{}
+}
+
+// Watch 监听 dirs（不递归），ctx 结束时停止
+// debounce <= 0 时默认 100ms，最长等待默认为 debounce 的 10 倍，见 SetMaxWait
+func Watch(ctx context.Context, debounce time.Duration, dirs ...string) (*Watcher, error) {
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		if err = fw.Add(dir); err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+
+	if debounce <= 0 {
+		debounce = time.Millisecond * 100
+	}
+
+	w := &Watcher{
+		w:        fw,
+		debounce: debounce,
+		maxWait:  debounce * 10,
+		subs:     make(map[int]*subscriber),
+		errs:     make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+
+	go w.run(ctx)
+	return w, nil
+}
+
+// Add 追加监听目录
+func (w *Watcher) Add(dir string) error {
+	return w.w.Add(dir)
+}
+
+// SetMaxWait 变化持续不断时，自第一次变化起最多等待 d 即分发，d 小于 debounce 时按 debounce
+func (w *Watcher) SetMaxWait(d time.Duration) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.maxWait = max(d, w.debounce)
+}
+
+// Subscribe 订阅文件名（不含目录）匹配 pattern 的变化，pattern 语法同 filepath.Match，为空时匹配全部
+// 回调在 Watcher 的协程中依次执行，返回的函数用于取消订阅
+func (w *Watcher) Subscribe(pattern string, fn func(events []Event)) (cancel func()) {
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subs[id] = &subscriber{
+		pattern: pattern,
+		fn:      fn,
+	}
+
+	return func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		delete(w.subs, id)
+	}
+}
+
+// Errors 监听出错，未读取时丢弃
+func (w *Watcher) Errors() <-chan error {
+	return w.errs
+}
+
+// Done 已停止监听
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+func (w *Watcher) run(ctx context.Context) {
+
+	defer close(w.done)
+	defer w.w.Close()
+
+	var (
+		pending  = make(map[string]Op)
+		order    []string
+		deadline time.Time // 本批最晚分发时间
+		timer    = time.NewTimer(w.debounce)
+	)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+
+		case <-ctx.Done():
+			return
+
+		case e, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			now := time.Now()
+			if len(order) < 1 {
+				w.mutex.Lock()
+				deadline = now.Add(w.maxWait)
+				w.mutex.Unlock()
+			}
+			if _, exist := pending[e.Name]; !exist {
+				order = append(order, e.Name)
+			}
+			pending[e.Name] |= e.Op
+			timer.Reset(min(w.debounce, deadline.Sub(now)))
+
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.errs <- err:
+			default:
+			}
+
+		case <-timer.C:
+			events := make([]Event, 0, len(order))
+			for _, name := range order {
+				events = append(events, Event{Name: name, Op: pending[name]})
+			}
+			pending = make(map[string]Op)
+			order = nil
+			w.dispatch(events)
+		}
+	}
+}
+
+func (w *Watcher) dispatch(events []Event) {
+
+	w.mutex.Lock()
+	subs := make([]*subscriber, 0, len(w.subs))
+	for i := 0; i < w.nextID; i++ {
+		if s, ok := w.subs[i]; ok {
+			subs = append(subs, s)
+		}
+	}
+	w.mutex.Unlock()
+
+	for _, s := range subs {
+
+		matched := events
+		if s.pattern != "" {
+			matched = nil
+			for _, e := range events {
+				if ok, _ := filepath.Match(s.pattern, filepath.Base(e.Name)); ok {
+					matched = append(matched, e)
+				}
+			}
+		}
+
+		if len(matched) > 0 {
+			s.fn(matched)
+		}
+	}
+}
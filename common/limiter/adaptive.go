@@ -0,0 +1,238 @@
+package limiter
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Outcome 一次调用的结果
+type Outcome uint8
+
+const (
+	Success Outcome = iota // 成功，参与调整
+	Dropped                // 失败或过载，参与调整
+	Ignore                 // 不参与调整，例如调用方主动取消
+)
+
+// Algorithm 并发上限调整算法
+type Algorithm interface {
+	// Update 根据一次调用的耗时与结果返回新的并发上限
+	Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64
+}
+
+// AIMD 加性增、乘性减：未拥塞且并发用满一半以上时 +1，失败或超时时按比例减少
+type AIMD struct {
+	Timeout time.Duration // 耗时超过此值视为拥塞，0 表示只看失败
+	Backoff float64       // 拥塞时乘以此系数，默认 0.9
+}
+
+func (a *AIMD) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+
+	if dropped || (a.Timeout > 0 && rtt > a.Timeout) {
+		backoff := a.Backoff
+		if backoff <= 0 || backoff >= 1 {
+			backoff = 0.9
+		}
+		return limit * backoff
+	}
+
+	if float64(inflight)*2 >= limit {
+		return limit + 1
+	}
+	return limit
+}
+
+// Gradient 按耗时梯度调整：短期耗时相对长期平均变大时收缩，否则按 sqrt(limit) 增长
+type Gradient struct {
+	Tolerance float64 // 容忍短期耗时为长期平均的倍数，默认 1.5
+	Smoothing float64 // 调整平滑系数，默认 0.2
+	Window    int     // 长期平均的样本窗口，默认 600
+	mutex     sync.Mutex
+	longRTT   float64
+}
+
+func (a *Gradient) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	tolerance, smoothing, window := a.Tolerance, a.Smoothing, a.Window
+	if tolerance <= 0 {
+		tolerance = 1.5
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+	if window <= 0 {
+		window = 600
+	}
+
+	short := float64(rtt)
+	if a.longRTT == 0 {
+		a.longRTT = short
+	} else {
+		a.longRTT += (short - a.longRTT) / float64(window)
+	}
+
+	gradient := 0.5
+	if !dropped && short > 0 {
+		gradient = math.Max(0.5, math.Min(1, tolerance*a.longRTT/short))
+	}
+
+	// 并发未用满一半时不增长，避免空闲时上限虚高
+	if float64(inflight)*2 < limit && gradient >= 1 {
+		return limit
+	}
+
+	next := limit*gradient + math.Sqrt(limit)
+	return limit*(1-smoothing) + next*smoothing
+}
+
+// Adaptive 自适应并发限制器，根据经过它的调用的耗时与失败自动调整并发上限
+type Adaptive struct {
+	mutex    sync.Mutex
+	algo     Algorithm
+	limit    float64
+	min      float64
+	max      float64
+	inflight int
+	waiters  list.List // chan struct{}
+}
+
+type AdaptiveOption func(a *Adaptive)
+
+func NewAdaptive(algo Algorithm, ops ...AdaptiveOption) *Adaptive {
+
+	if algo == nil {
+		algo = &AIMD{}
+	}
+
+	a := &Adaptive{
+		algo:  algo,
+		limit: 20,
+		min:   1,
+		max:   200,
+	}
+
+	for _, op := range ops {
+		op(a)
+	}
+
+	return a
+}
+
+// WithLimits 初始、最小、最大并发，默认 20、1、200
+func WithLimits(initial, min, max int) AdaptiveOption {
+	return func(a *Adaptive) {
+		if min < 1 {
+			min = 1
+		}
+		if max < min {
+			max = min
+		}
+		a.min, a.max = float64(min), float64(max)
+		a.limit = math.Max(a.min, math.Min(a.max, float64(initial)))
+	}
+}
+
+// Limit 当前并发上限
+func (a *Adaptive) Limit() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return int(a.limit)
+}
+
+// Inflight 当前并发数
+func (a *Adaptive) Inflight() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return a.inflight
+}
+
+// TryAcquire 不等待，并发已满时返回 false
+func (a *Adaptive) TryAcquire() (release func(Outcome), ok bool) {
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.inflight >= int(a.limit) {
+		return nil, false
+	}
+
+	return a.acquired(), true
+}
+
+// Acquire 等待并发名额，调用结束后需调用 release 报告结果
+func (a *Adaptive) Acquire(ctx context.Context) (release func(Outcome), err error) {
+
+	a.mutex.Lock()
+	if a.inflight < int(a.limit) && a.waiters.Len() == 0 {
+		release = a.acquired()
+		a.mutex.Unlock()
+		return
+	}
+
+	ch := make(chan struct{})
+	e := a.waiters.PushBack(ch)
+	a.mutex.Unlock()
+
+	select {
+	case <-ch:
+		// 名额已在 wake 中计入
+		return a.release(time.Now()), nil
+	case <-ctx.Done():
+		a.mutex.Lock()
+		defer a.mutex.Unlock()
+		select {
+		case <-ch:
+			// 取消的同时被唤醒，归还名额
+			a.inflight--
+			a.wake()
+		default:
+			a.waiters.Remove(e)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// acquired 需持有锁
+func (a *Adaptive) acquired() func(Outcome) {
+	a.inflight++
+	return a.release(time.Now())
+}
+
+func (a *Adaptive) release(start time.Time) func(Outcome) {
+
+	var once sync.Once
+	return func(o Outcome) {
+		once.Do(func() {
+
+			rtt := time.Since(start)
+
+			a.mutex.Lock()
+			defer a.mutex.Unlock()
+
+			inflight := a.inflight
+			a.inflight--
+
+			if o != Ignore {
+				limit := a.algo.Update(a.limit, rtt, inflight, o == Dropped)
+				a.limit = math.Max(a.min, math.Min(a.max, limit))
+			}
+
+			a.wake()
+		})
+	}
+}
+
+// wake 按顺序唤醒等待者，需持有锁
+func (a *Adaptive) wake() {
+	for a.inflight < int(a.limit) && a.waiters.Len() > 0 {
+		ch := a.waiters.Remove(a.waiters.Front()).(chan struct{})
+		a.inflight++
+		close(ch)
+	}
+}
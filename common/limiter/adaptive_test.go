@@ -0,0 +1,136 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveAIMD(t *testing.T) {
+
+	a := NewAdaptive(&AIMD{Timeout: time.Millisecond * 50}, WithLimits(4, 1, 10))
+
+	var releases []func(Outcome)
+	for i := 0; i < 4; i++ {
+		release, ok := a.TryAcquire()
+		if !ok {
+			t.Fatalf("acquire %d", i)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := a.TryAcquire(); ok {
+		t.Fatal("limit exceeded")
+	}
+
+	// 超时视为拥塞
+	time.Sleep(time.Millisecond * 60)
+	releases[0](Success)
+	if l := a.Limit(); l != 3 {
+		t.Fatalf("limit %d after timeout", l)
+	}
+
+	// 重复调用 release 无效
+	releases[0](Success)
+	if a.Inflight() != 3 || a.Limit() != 3 {
+		t.Fatalf("inflight %d limit %d", a.Inflight(), a.Limit())
+	}
+
+	for _, r := range releases[1:] {
+		r(Ignore)
+	}
+	if a.Inflight() != 0 {
+		t.Fatalf("inflight %d", a.Inflight())
+	}
+}
+
+func TestAdaptiveAdjust(t *testing.T) {
+
+	a := NewAdaptive(&AIMD{}, WithLimits(4, 2, 6))
+
+	// 并发用满时成功调用逐步增长到上限
+	for i := 0; i < 10; i++ {
+		var releases []func(Outcome)
+		for j := 0; j < a.Limit(); j++ {
+			r, _ := a.TryAcquire()
+			releases = append(releases, r)
+		}
+		for _, r := range releases {
+			r(Success)
+		}
+	}
+	if l := a.Limit(); l != 6 {
+		t.Fatalf("grow to %d", l)
+	}
+
+	for i := 0; i < 20; i++ {
+		r, _ := a.TryAcquire()
+		r(Dropped)
+	}
+	if l := a.Limit(); l != 2 {
+		t.Fatalf("shrink to %d", l)
+	}
+
+	r, _ := a.TryAcquire()
+	r(Ignore)
+	if l := a.Limit(); l != 2 {
+		t.Fatalf("ignore changed limit to %d", l)
+	}
+}
+
+func TestAdaptiveWait(t *testing.T) {
+
+	a := NewAdaptive(&Gradient{}, WithLimits(2, 1, 10))
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		peak, cur int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := a.Acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mutex.Lock()
+			if cur++; cur > peak {
+				peak = cur
+			}
+			mutex.Unlock()
+			time.Sleep(time.Millisecond * 5)
+			mutex.Lock()
+			cur--
+			mutex.Unlock()
+			release(Success)
+		}()
+	}
+	wg.Wait()
+
+	if peak > 10 || a.Inflight() != 0 {
+		t.Fatalf("peak %d inflight %d", peak, a.Inflight())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	var held []func(Outcome)
+	for {
+		r, ok := a.TryAcquire()
+		if !ok {
+			break
+		}
+		held = append(held, r)
+	}
+	if _, err := a.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect deadline, got %v", err)
+	}
+	for _, r := range held {
+		r(Ignore)
+	}
+	if a.Inflight() != 0 {
+		t.Fatalf("inflight %d", a.Inflight())
+	}
+}
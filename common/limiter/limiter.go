@@ -1,16 +1,31 @@
 package limiter
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
 )
 
+var (
+	ErrStopped  = fmt.Errorf("limiter: 已停止")
+	ErrExceeded = fmt.Errorf("limiter: 请求令牌数超过桶容量")
+	ErrInvalid  = fmt.Errorf("limiter: 请求令牌数需大于 0")
+)
+
 // Limiter 令牌桶限速器
+// 令牌在取用时按经过的周期数补发，不需要后台协程
 type Limiter struct {
-	tokens      chan struct{} // 令牌桶
+	mutex       sync.Mutex
 	duration    time.Duration // 颁发令牌周期
 	assignCount int           // 每次颁发几个令牌，决定最小并发数
 	concurrency int           // 令牌桶容量上限，决定最大并发数
+	tokens      int           // 当前令牌数，被预约时可为负
+	last        time.Time     // 上次颁发时间
 	done        chan struct{} // <-Done()
+	stopped     bool
+	ch          chan struct{} // Chan()
+	chOnce      sync.Once
 }
 
 func New(duration time.Duration, assignCount, concurrency int) *Limiter {
@@ -31,51 +46,59 @@ func NewWithDelay(delay, duration time.Duration, assignCount, concurrency int) *
 
 func (l *Limiter) start(delay time.Duration) *Limiter {
 
-	l.tokens = make(chan struct{}, l.concurrency)
 	l.done = make(chan struct{})
+	l.fix()
 
-	go func() {
+	now := time.Now()
+	if delay > 0 {
+		// delay 后第一次颁发
+		l.last = now.Add(delay - l.duration)
+	} else {
+		// 开始先分配一次，避免无效等待
+		l.last = now
+		l.tokens = min(l.assignCount, l.concurrency)
+	}
 
-		defer close(l.tokens)
+	return l
+}
+
+func (l *Limiter) fix() {
+	if l.duration <= 0 {
+		l.duration = time.Second
+	}
+	if l.assignCount < 1 {
+		l.assignCount = 1
+	}
+	if l.concurrency < 1 {
+		l.concurrency = l.assignCount
+	}
+}
 
-		tick := time.NewTicker(l.duration)
-		defer tick.Stop()
+// advance 补发截至 now 的令牌
+func (l *Limiter) advance(now time.Time) {
 
-		for {
-			select {
-			case <-l.done:
-				return
-			case <-tick.C:
-				l.assign()
-			}
-		}
-	}()
+	if now.Before(l.last) {
+		return
+	}
 
-	// 开始先分配一次，避免无效等待
-	if delay > 0 {
-		time.AfterFunc(delay, l.assign)
-	} else {
-		l.assign()
+	n := int(now.Sub(l.last) / l.duration)
+	if n < 1 {
+		return
 	}
 
-	return l
+	l.tokens = min(l.concurrency, l.tokens+n*l.assignCount)
+	l.last = l.last.Add(time.Duration(n) * l.duration)
 }
 
 // Stop 终止限速器
 func (l *Limiter) Stop() *Limiter {
-	close(l.done)
-	return l
-}
-
-func (l *Limiter) assign() {
-	for i := 0; i < l.assignCount; i++ {
-		select {
-		case <-l.done:
-			return
-		case l.tokens <- struct{}{}:
-		default:
-		}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.stopped {
+		l.stopped = true
+		close(l.done)
 	}
+	return l
 }
 
 // Done 已停止生成新令牌
@@ -83,12 +106,144 @@ func (l *Limiter) Done() <-chan struct{} {
 	return l.done
 }
 
-// Wait 阻塞等待
-func (l *Limiter) Wait() {
-	<-l.tokens
+// Allow 立即取一个令牌，没有令牌时返回 false
+func (l *Limiter) Allow() bool {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.stopped {
+		return false
+	}
+
+	l.advance(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
 }
 
-// Chan 获取令牌
+// Reserve 预约 n 个令牌，返回需要等待的时长，调用方等待后即可使用
+// n 不大于 0 时返回 ErrInvalid，超过桶容量时返回 ErrExceeded，均不消耗令牌
+func (l *Limiter) Reserve(n int) (time.Duration, error) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.reserve(time.Now(), n)
+}
+
+func (l *Limiter) reserve(now time.Time, n int) (time.Duration, error) {
+
+	if l.stopped {
+		return 0, ErrStopped
+	}
+
+	if n < 1 {
+		return 0, ErrInvalid
+	}
+	if n > l.concurrency {
+		return 0, ErrExceeded
+	}
+
+	l.advance(now)
+	if l.tokens -= n; l.tokens >= 0 {
+		return 0, nil
+	}
+
+	// 还需补发几个周期
+	ticks := (-l.tokens + l.assignCount - 1) / l.assignCount
+	return l.last.Add(time.Duration(ticks) * l.duration).Sub(now), nil
+}
+
+// Cancel 归还 Reserve 预约但未使用的 n 个令牌，n < 1 时忽略
+func (l *Limiter) Cancel(n int) {
+
+	if n < 1 {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.tokens = min(l.concurrency, l.tokens+n)
+}
+
+// WaitCtx 阻塞等待一个令牌，ctx 结束或限速器停止时返回错误
+func (l *Limiter) WaitCtx(ctx context.Context) error {
+
+	d, err := l.Reserve(1)
+	if err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.Cancel(1)
+		return ctx.Err()
+	case <-l.done:
+		return ErrStopped
+	}
+}
+
+// Wait 阻塞等待，限速器停止时返回 ErrStopped
+func (l *Limiter) Wait() error {
+	return l.WaitCtx(context.Background())
+}
+
+// SetRate 运行时调整颁发周期与每次颁发数
+func (l *Limiter) SetRate(duration time.Duration, assignCount int) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.advance(time.Now())
+	l.duration = duration
+	l.assignCount = assignCount
+	l.fix()
+}
+
+// SetBurst 运行时调整令牌桶容量
+func (l *Limiter) SetBurst(concurrency int) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.advance(time.Now())
+	l.concurrency = concurrency
+	l.fix()
+	l.tokens = min(l.tokens, l.concurrency)
+}
+
+// Chan 获取令牌，限速器停止后关闭
+// Deprecated: 使用 WaitCtx；首次调用会启动一个协程预取令牌
 func (l *Limiter) Chan() <-chan struct{} {
-	return l.tokens
+
+	l.chOnce.Do(func() {
+
+		l.ch = make(chan struct{})
+
+		go func() {
+			defer close(l.ch)
+			for l.Wait() == nil {
+				select {
+				case <-l.done:
+					return
+				case l.ch <- struct{}{}:
+				}
+			}
+		}()
+	})
+
+	return l.ch
 }
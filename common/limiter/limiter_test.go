@@ -1,6 +1,7 @@
 package limiter
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -38,3 +39,110 @@ func TestLimiter(t *testing.T) {
 	wg.Wait()
 	t.Log("Done")
 }
+
+func TestLimiterAllow(t *testing.T) {
+
+	l := New(time.Millisecond*50, 2, 3)
+	defer l.Stop()
+
+	if !l.Allow() || !l.Allow() || l.Allow() {
+		t.Fatal("expect 2 initial tokens")
+	}
+
+	time.Sleep(time.Millisecond * 160) // 3 个周期，补发 6 个，但容量只有 3
+	var n int
+	for l.Allow() {
+		n++
+	}
+	if n != 3 {
+		t.Fatalf("burst %d", n)
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+
+	l := New(time.Millisecond*100, 1, 2)
+	defer l.Stop()
+
+	if d, err := l.Reserve(1); err != nil || d != 0 {
+		t.Fatalf("%s %v", d, err)
+	}
+
+	d1, _ := l.Reserve(1)
+	d2, _ := l.Reserve(1)
+	if d1 <= 0 || d1 > time.Millisecond*100 || d2 <= d1 {
+		t.Fatalf("delays %s %s", d1, d2)
+	}
+
+	for _, c := range []struct {
+		n   int
+		err error
+	}{
+		{0, ErrInvalid},
+		{-1, ErrInvalid},
+		{3, ErrExceeded},
+	} {
+		if d, err := l.Reserve(c.n); err != c.err || d != 0 {
+			t.Errorf("Reserve(%d): %s %v", c.n, d, err)
+		}
+	}
+
+	// 出错的预约、非正数的归还都不消耗令牌
+	l.Cancel(0)
+	l.Cancel(-5)
+	if d3, _ := l.Reserve(1); d3-d2 < time.Millisecond*90 || d3-d2 > time.Millisecond*100 {
+		t.Fatalf("delays %s %s", d2, d3)
+	}
+}
+
+func TestLimiterWaitCtx(t *testing.T) {
+
+	l := NewWithDelay(time.Millisecond*30, time.Hour, 1, 1)
+
+	start := time.Now()
+	if err := l.WaitCtx(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Millisecond*25 {
+		t.Fatalf("waited %s", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := l.WaitCtx(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect deadline, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		l.Stop()
+	}()
+	if err := l.Wait(); err != ErrStopped {
+		t.Fatalf("expect stopped, got %v", err)
+	}
+	if l.Allow() {
+		t.Fatal("stopped limiter allowed")
+	}
+	if _, ok := <-l.Chan(); ok {
+		t.Fatal("Chan not closed")
+	}
+}
+
+func TestLimiterSetRate(t *testing.T) {
+
+	l := New(time.Hour, 1, 1)
+	defer l.Stop()
+	l.Allow()
+
+	l.SetRate(time.Millisecond*10, 5)
+	l.SetBurst(5)
+	time.Sleep(time.Millisecond * 15)
+
+	var n int
+	for l.Allow() {
+		n++
+	}
+	if n != 5 {
+		t.Fatalf("got %d", n)
+	}
+}
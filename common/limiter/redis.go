@@ -0,0 +1,646 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	REDIS "github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/jack0829/letsgo/common/sets"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable Redis 出错后的退避期内不再访问 Redis
+var ErrUnavailable = fmt.Errorf("limiter: Redis 暂不可用")
+
+// RateLimiter 本地与分布式限速器的共同方法
+// Stop 的返回值类型不同，不在此列
+type RateLimiter interface {
+	Allow() bool
+	Reserve(n int) (time.Duration, error)
+	Cancel(n int)
+	WaitCtx(ctx context.Context) error
+	Wait() error
+	SetRate(duration time.Duration, assignCount int)
+	SetBurst(concurrency int)
+	Done() <-chan struct{}
+}
+
+var (
+	_ RateLimiter = (*Limiter)(nil)
+	_ RateLimiter = (*Redis)(nil)
+)
+
+// 令牌桶：与 Limiter 相同，每个周期颁发 assign 个令牌，容量 capacity
+// 返回需等待的毫秒数，仅尝试（allow）且令牌不足时返回 -1
+// 时间取自 Redis 避免副本间时钟偏差，低于 5.0 的 Redis 需 replicate_commands 才能在 TIME 之后写入
+var tokenBucketScript = REDIS.NewScript(`
+local duration = tonumber(ARGV[1])
+local assign = tonumber(ARGV[2])
+local capacity = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local allow = ARGV[5] == "1"
+
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local s = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(s[1])
+local last = tonumber(s[2])
+if tokens == nil or last == nil then
+	tokens = math.min(assign, capacity)
+	last = now
+end
+
+if now > last then
+	local ticks = math.floor((now - last) / duration)
+	if ticks > 0 then
+		tokens = math.min(capacity, tokens + ticks * assign)
+		last = last + ticks * duration
+	end
+end
+
+local wait = 0
+if allow and tokens < n then
+	wait = -1
+else
+	tokens = tokens - n
+	if tokens < 0 then
+		wait = last + math.ceil(-tokens / assign) * duration - now
+	end
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tokens, "last", last)
+redis.call("PEXPIRE", KEYS[1], math.max(wait, 0) + math.ceil(capacity / assign) * duration + duration)
+return wait
+`)
+
+// 滑动窗口：任意 window 内最多 limit 次
+// 预约的请求以未来时间记入窗口，成员为 ARGV[6] .. "-" .. i，归还时据此移除；返回值含义同令牌桶
+var slidingWindowScript = REDIS.NewScript(`
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local n = tonumber(ARGV[4])
+local allow = ARGV[5] == "1"
+local id = ARGV[6]
+
+redis.replicate_commands()
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+
+local wait = 0
+local over = count + n - limit
+if over > 0 then
+	if allow then
+		return -1
+	end
+	local r = redis.call("ZRANGE", KEYS[1], over - 1, over - 1, "WITHSCORES")
+	wait = math.max(tonumber(r[2]) + window - now, 0)
+end
+
+for i = 1, n do
+	redis.call("ZADD", KEYS[1], now + wait, id .. "-" .. i)
+end
+redis.call("PEXPIRE", KEYS[1], wait + window)
+return wait
+`)
+
+// 归还令牌桶的预约；滑动窗口直接 ZREM 本次记入的成员
+var cancelScript = REDIS.NewScript(`
+local capacity = tonumber(ARGV[1])
+local n = tonumber(ARGV[2])
+
+local tokens = tonumber(redis.call("HGET", KEYS[1], "tokens"))
+if tokens ~= nil then
+	redis.call("HSET", KEYS[1], "tokens", math.min(capacity, tokens + n))
+end
+return 0
+`)
+
+// Redis 基于 Redis 的分布式限速器，多个副本共享同一个 key 的配额
+// Redis 不可用时退回进程内的 Limiter
+type Redis struct {
+	ctx         context.Context
+	c           REDIS.Cmdable
+	key         string
+	mutex       sync.RWMutex
+	duration    time.Duration
+	assignCount int
+	concurrency int
+	window      bool
+	fallback    bool
+	local       *Limiter
+	done        chan struct{} // <-Done()
+	stopped     bool
+	idle        time.Duration               // 租户闲置多久后移除
+	tenants     *sets.Cache[string, *Redis] // 首次调用 Tenant 时创建
+	backoff     *backoff                    // 所有租户共用
+	reserved    *reserved
+}
+
+// reserved 滑动窗口模式下本实例 Reserve 记入且尚未过期的成员，按预约顺序，供 Cancel 归还
+type reserved struct {
+	mutex sync.Mutex
+	list  []member
+}
+
+type member struct {
+	name   string
+	expire time.Time // 移出窗口的时间，之后无需归还
+}
+
+func (m *reserved) prune(now time.Time) {
+	i := 0
+	for i < len(m.list) && now.After(m.list[i].expire) {
+		i++
+	}
+	m.list = m.list[i:]
+}
+
+func (m *reserved) push(names []string, expire time.Time) {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.prune(time.Now())
+	for _, name := range names {
+		m.list = append(m.list, member{name, expire})
+	}
+}
+
+// pop 取出最近的 n 个成员
+func (m *reserved) pop(n int) []string {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.prune(time.Now())
+	n = min(n, len(m.list))
+	names := make([]string, 0, n)
+	for _, v := range m.list[len(m.list)-n:] {
+		names = append(names, v.name)
+	}
+	m.list = m.list[:len(m.list)-n]
+	return names
+}
+
+// backoff Redis 出错后按指数退避，期间直接退回本地限速（或返回 ErrUnavailable），避免每次都等待超时
+type backoff struct {
+	mutex    sync.Mutex
+	min, max time.Duration
+	delay    time.Duration // 下次出错时的退避时长
+	until    time.Time
+}
+
+func (b *backoff) open(now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return now.Before(b.until)
+}
+
+func (b *backoff) fail(now time.Time) {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.delay < b.min {
+		b.delay = b.min
+	}
+	b.until = now.Add(b.delay)
+	b.delay = min(b.delay*2, b.max)
+}
+
+func (b *backoff) succeed() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.delay, b.until = 0, time.Time{}
+}
+
+type RedisOption func(r *Redis)
+
+// NewRedis 参数含义与 New 相同；滑动窗口模式下为每 duration 最多 assignCount 次
+func NewRedis(
+	ctx context.Context,
+	cmd REDIS.Cmdable,
+	key string,
+	duration time.Duration,
+	assignCount, concurrency int,
+	ops ...RedisOption,
+) *Redis {
+
+	r := &Redis{
+		ctx:         ctx,
+		c:           cmd,
+		key:         key,
+		duration:    duration,
+		assignCount: assignCount,
+		concurrency: concurrency,
+		fallback:    true,
+		idle:        time.Minute * 10,
+		backoff:     &backoff{min: time.Second, max: time.Minute},
+	}
+
+	for _, op := range ops {
+		op(r)
+	}
+
+	return r.init()
+}
+
+func (r *Redis) init() *Redis {
+
+	l := &Limiter{
+		duration:    r.duration,
+		assignCount: r.assignCount,
+		concurrency: r.concurrency,
+	}
+	l.fix()
+	r.duration, r.assignCount, r.concurrency = l.duration, l.assignCount, l.concurrency
+	r.reserved = &reserved{}
+	r.done = make(chan struct{})
+
+	if r.fallback {
+		r.local = l.start(0)
+	}
+	return r
+}
+
+// SlidingWindow 使用滑动窗口计数代替令牌桶
+func SlidingWindow(r *Redis) {
+	r.window = true
+}
+
+// WithoutFallback Redis 不可用时直接返回错误，不退回本地限速
+func WithoutFallback(r *Redis) {
+	r.fallback = false
+}
+
+// WithBackoff Redis 出错后的退避时长，从 initial 开始每次翻倍至 maximum，默认 1s ~ 1m
+func WithBackoff(initial, maximum time.Duration) RedisOption {
+	return func(r *Redis) {
+		if initial > 0 {
+			r.backoff.min = initial
+			r.backoff.max = max(initial, maximum)
+		}
+	}
+}
+
+// WithTenantIdle 租户闲置超过 d 后从缓存中移除，默认 10 分钟
+// 移除只释放本进程的内存，Redis 中的计数不受影响，再次调用 Tenant 时重新创建
+func WithTenantIdle(d time.Duration) RedisOption {
+	return func(r *Redis) {
+		if d > 0 {
+			r.idle = d
+		}
+	}
+}
+
+// Tenant 按租户区分 key 的限速器，参数与当前限速器相同
+// 闲置超过 WithTenantIdle 的租户会被移除，id 可按用户或请求动态生成
+func (r *Redis) Tenant(id string) *Redis {
+
+	tenants := r.tenantCache(true)
+	if tenants == nil {
+		// 已停止，不再缓存
+		return r.tenant(id)
+	}
+
+	t, _ := tenants.GetOrLoad(id, func(id string) (*Redis, time.Duration, error) {
+		return r.tenant(id), r.idle, nil
+	})
+	// 每次访问续期
+	tenants.SetTTL(id, t, r.idle)
+	return t
+}
+
+// tenantCache 租户缓存，create 为 true 时按需创建；已停止时不再创建
+func (r *Redis) tenantCache(create bool) *sets.Cache[string, *Redis] {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.tenants == nil && create && !r.stopped {
+		r.tenants = sets.NewCache[string, *Redis](sets.WithJanitor(r.ctx, r.idle))
+	}
+	return r.tenants
+}
+
+func (r *Redis) tenant(id string) *Redis {
+
+	duration, assignCount, concurrency := r.rate()
+	t := (&Redis{
+		ctx:         r.ctx,
+		c:           r.c,
+		key:         r.key + ":" + id,
+		duration:    duration,
+		assignCount: assignCount,
+		concurrency: concurrency,
+		window:      r.window,
+		fallback:    r.fallback,
+		idle:        r.idle,
+		backoff:     r.backoff,
+	}).init()
+
+	select {
+	case <-r.done:
+		t.Stop()
+	default:
+	}
+	return t
+}
+
+// rate 当前的颁发周期、每次颁发数与容量
+func (r *Redis) rate() (time.Duration, int, int) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.duration, r.assignCount, r.concurrency
+}
+
+// SetRate 运行时调整颁发周期与每次颁发数，同时调整本地限速与已有的租户
+// 参数随每次请求传给 Redis，只影响本进程；多个副本需各自调整
+func (r *Redis) SetRate(duration time.Duration, assignCount int) {
+
+	r.mutex.Lock()
+	l := &Limiter{duration: duration, assignCount: assignCount, concurrency: r.concurrency}
+	l.fix()
+	r.duration, r.assignCount = l.duration, l.assignCount
+	r.mutex.Unlock()
+
+	if r.local != nil {
+		r.local.SetRate(duration, assignCount)
+	}
+	r.eachTenant(func(t *Redis) {
+		t.SetRate(duration, assignCount)
+	})
+}
+
+// SetBurst 运行时调整令牌桶容量，其余同 SetRate
+func (r *Redis) SetBurst(concurrency int) {
+
+	r.mutex.Lock()
+	l := &Limiter{duration: r.duration, assignCount: r.assignCount, concurrency: concurrency}
+	l.fix()
+	r.concurrency = l.concurrency
+	r.mutex.Unlock()
+
+	if r.local != nil {
+		r.local.SetBurst(concurrency)
+	}
+	r.eachTenant(func(t *Redis) {
+		t.SetBurst(concurrency)
+	})
+}
+
+// Stop 终止限速器，连同本地限速与全部租户；之后 Allow 返回 false，Reserve、Wait 返回 ErrStopped
+func (r *Redis) Stop() *Redis {
+
+	r.mutex.Lock()
+	if r.stopped {
+		r.mutex.Unlock()
+		return r
+	}
+	r.stopped = true
+	close(r.done)
+	r.mutex.Unlock()
+
+	if r.local != nil {
+		r.local.Stop()
+	}
+	if tenants := r.tenantCache(false); tenants != nil {
+		tenants.Each(func(_ string, t *Redis) {
+			t.Stop()
+		})
+		tenants.Close()
+	}
+	return r
+}
+
+// Done 已停止
+func (r *Redis) Done() <-chan struct{} {
+	return r.done
+}
+
+func (r *Redis) isStopped() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.stopped
+}
+
+// eachTenant 遍历已创建的租户
+func (r *Redis) eachTenant(fn func(t *Redis)) {
+
+	if tenants := r.tenantCache(false); tenants != nil {
+		tenants.Each(func(_ string, t *Redis) {
+			fn(t)
+		})
+	}
+}
+
+// Key Redis 中的 key
+func (r *Redis) Key() string {
+	return r.key
+}
+
+// run 执行限速脚本，滑动窗口模式下 id 为记入成员的前缀
+func (r *Redis) run(ctx context.Context, n int, allow bool, id string) (time.Duration, error) {
+
+	if r.backoff.open(time.Now()) {
+		return 0, ErrUnavailable
+	}
+
+	script := tokenBucketScript
+	if r.window {
+		script = slidingWindowScript
+	}
+
+	duration, assignCount, concurrency := r.rate()
+
+	a := "0"
+	if allow {
+		a = "1"
+	}
+
+	ms, err := script.Run(
+		ctx,
+		r.c,
+		[]string{r.key},
+		duration.Milliseconds(),
+		assignCount,
+		concurrency,
+		n,
+		a,
+		id,
+	).Int64()
+	if err != nil {
+		// 调用方取消不算 Redis 故障
+		if ctx.Err() == nil {
+			r.backoff.fail(time.Now())
+		}
+		return 0, err
+	}
+	r.backoff.succeed()
+
+	if ms < 0 {
+		return -1, nil
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func (r *Redis) limit() int {
+	_, assignCount, concurrency := r.rate()
+	if r.window {
+		return assignCount
+	}
+	return concurrency
+}
+
+// Allow 立即取一个令牌，没有令牌时返回 false
+func (r *Redis) Allow() bool {
+
+	if r.isStopped() {
+		return false
+	}
+
+	d, err := r.run(r.ctx, 1, true, uuid.NewString())
+	if err != nil {
+		return r.local != nil && r.local.Allow()
+	}
+
+	return d == 0
+}
+
+// Reserve 预约 n 个令牌，返回需要等待的时长；n 不合法时的错误同 Limiter.Reserve
+func (r *Redis) Reserve(n int) (time.Duration, error) {
+
+	d, members, err := r.reserve(r.ctx, n)
+	if err == nil && len(members) > 0 {
+		duration, _, _ := r.rate()
+		r.reserved.push(members, time.Now().Add(d+duration))
+	}
+	return d, err
+}
+
+// reserve 预约 n 个令牌，滑动窗口模式下同时返回记入 Redis 的成员；退回本地限速时成员为空
+func (r *Redis) reserve(ctx context.Context, n int) (time.Duration, []string, error) {
+
+	if r.isStopped() {
+		return 0, nil, ErrStopped
+	}
+
+	if n < 1 {
+		return 0, nil, ErrInvalid
+	}
+	if n > r.limit() {
+		return 0, nil, ErrExceeded
+	}
+
+	var (
+		id      = uuid.NewString()
+		members []string
+	)
+	if r.window {
+		for i := 1; i <= n; i++ {
+			members = append(members, fmt.Sprintf("%s-%d", id, i))
+		}
+	}
+
+	d, err := r.run(ctx, n, false, id)
+	if err != nil {
+		if r.local != nil {
+			d, err = r.local.Reserve(n)
+		}
+		return d, nil, err
+	}
+
+	return d, members, nil
+}
+
+// Cancel 归还 Reserve 预约但未使用的 n 个令牌
+// 滑动窗口模式下只移除本实例最近 Reserve 记入且尚未移出窗口的成员，不影响其他调用方
+func (r *Redis) Cancel(n int) {
+
+	if n < 1 {
+		return
+	}
+
+	if r.window {
+		members := r.reserved.pop(n)
+		r.cancel(n, members)
+		return
+	}
+
+	r.cancel(n, nil)
+}
+
+// cancel 归还预约；滑动窗口模式下移除 members，不足 n 的部分视为本地限速的预约
+func (r *Redis) cancel(n int, members []string) {
+
+	if r.window {
+		if len(members) > 0 && !r.backoff.open(time.Now()) {
+			if err := r.c.ZRem(r.ctx, r.key, toAny(members)...).Err(); err != nil && r.ctx.Err() == nil {
+				r.backoff.fail(time.Now())
+			}
+		}
+		if n -= len(members); n > 0 && r.local != nil {
+			r.local.Cancel(n)
+		}
+		return
+	}
+
+	if !r.backoff.open(time.Now()) {
+		err := cancelScript.Run(r.ctx, r.c, []string{r.key}, r.limit(), n).Err()
+		if err == nil {
+			return
+		}
+		if r.ctx.Err() == nil {
+			r.backoff.fail(time.Now())
+		}
+	}
+
+	if r.local != nil {
+		r.local.Cancel(n)
+	}
+}
+
+func toAny(list []string) []any {
+	items := make([]any, len(list))
+	for i, v := range list {
+		items[i] = v
+	}
+	return items
+}
+
+// WaitCtx 阻塞等待一个令牌；ctx 结束时归还已预约的令牌，限速器停止时返回 ErrStopped
+func (r *Redis) WaitCtx(ctx context.Context) error {
+
+	d, members, err := r.reserve(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.cancel(1, members)
+		return ctx.Err()
+	case <-r.done:
+		return ErrStopped
+	}
+}
+
+// Wait 阻塞等待一个令牌
+func (r *Redis) Wait() error {
+	return r.WaitCtx(r.ctx)
+}
@@ -0,0 +1,358 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	REDIS "github.com/go-redis/redis/v8"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 不可达的 Redis，验证退回本地限速
+func testUnreachableRedis() REDIS.Cmdable {
+	return REDIS.NewClient(&REDIS.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: time.Millisecond * 100,
+		MaxRetries:  -1,
+	})
+}
+
+func TestRedisFallback(t *testing.T) {
+
+	ctx := context.Background()
+	r := NewRedis(ctx, testUnreachableRedis(), "limiter:test", time.Hour, 2, 2)
+
+	if !r.Allow() || !r.Allow() || r.Allow() {
+		t.Fatal("expect 2 tokens from local fallback")
+	}
+
+	a, b := r.Tenant("a"), r.Tenant("b")
+	if a != r.Tenant("a") || a.Key() != "limiter:test:a" {
+		t.Fatalf("tenant key %s", a.Key())
+	}
+	if !a.Allow() || !b.Allow() {
+		t.Fatal("tenants should not share quota")
+	}
+
+	for _, n := range []int{0, -1, 3} {
+		if _, err := r.Reserve(n); err != ErrInvalid && err != ErrExceeded {
+			t.Fatalf("Reserve(%d): %v", n, err)
+		}
+	}
+
+	strict := NewRedis(ctx, testUnreachableRedis(), "limiter:test", time.Hour, 2, 2, WithoutFallback)
+	if strict.Allow() {
+		t.Fatal("expect deny without fallback")
+	}
+	if err := strict.Wait(); err == nil {
+		t.Fatal("expect redis error")
+	}
+}
+
+// countHook 统计发往 Redis 的命令数
+type countHook struct {
+	n atomic.Int32
+}
+
+func (h *countHook) BeforeProcess(ctx context.Context, _ REDIS.Cmder) (context.Context, error) {
+	h.n.Add(1)
+	return ctx, nil
+}
+
+func (h *countHook) AfterProcess(context.Context, REDIS.Cmder) error {
+	return nil
+}
+
+func (h *countHook) BeforeProcessPipeline(ctx context.Context, _ []REDIS.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *countHook) AfterProcessPipeline(context.Context, []REDIS.Cmder) error {
+	return nil
+}
+
+func TestRedisBackoff(t *testing.T) {
+
+	c := testUnreachableRedis().(*REDIS.Client)
+	hook := &countHook{}
+	c.AddHook(hook)
+
+	ctx := context.Background()
+	r := NewRedis(ctx, c, "limiter:test", time.Hour, 10, 10, WithBackoff(time.Millisecond*50, time.Millisecond*80))
+	strict := NewRedis(ctx, c, "limiter:strict", time.Hour, 10, 10, WithoutFallback, WithBackoff(time.Hour, time.Hour))
+
+	// 出错后退避期内不再访问 Redis，租户共用退避状态
+	for i := 0; i < 5; i++ {
+		if !r.Allow() || !r.Tenant("a").Allow() {
+			t.Fatal("expect local fallback")
+		}
+	}
+	if n := hook.n.Load(); n != 1 {
+		t.Fatalf("redis calls %d", n)
+	}
+
+	time.Sleep(time.Millisecond * 60)
+	r.Allow()
+	if n := hook.n.Load(); n != 2 {
+		t.Fatalf("redis calls after backoff %d", n)
+	}
+
+	// 退避翻倍：60ms 后仍在 100ms（上限 80ms）的退避期内
+	time.Sleep(time.Millisecond * 60)
+	r.Allow()
+	if n := hook.n.Load(); n != 2 {
+		t.Fatalf("redis calls during doubled backoff %d", n)
+	}
+
+	if strict.Allow() {
+		t.Fatal("expect deny without fallback")
+	}
+	if _, err := strict.Reserve(1); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expect unavailable, got %v", err)
+	}
+}
+
+// testRedis 验证 Lua 脚本用的 Redis：设置 LIMITER_REDIS_ADDR 时使用真实 Redis，否则使用 miniredis
+func testRedis(t *testing.T) REDIS.Cmdable {
+
+	addr := os.Getenv("LIMITER_REDIS_ADDR")
+	if addr == "" {
+		addr = miniredis.RunT(t).Addr()
+	}
+
+	c := REDIS.NewClient(&REDIS.Options{Addr: addr})
+	t.Cleanup(func() { c.Close() })
+	if err := c.Ping(context.Background()).Err(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func testRedisKey(t *testing.T, c REDIS.Cmdable) string {
+	key := fmt.Sprintf("limiter:test:%s:%d", t.Name(), time.Now().UnixNano())
+	t.Cleanup(func() { c.Del(context.Background(), key, key+":a", key+":b") })
+	return key
+}
+
+func TestRedisTokenBucket(t *testing.T) {
+
+	c := testRedis(t)
+	ctx := context.Background()
+	key := testRedisKey(t, c)
+	r := NewRedis(ctx, c, key, time.Millisecond*200, 1, 2, WithoutFallback)
+
+	// 开始时颁发 assignCount 个令牌
+	if !r.Allow() || r.Allow() {
+		t.Fatal("expect 1 initial token")
+	}
+
+	d1, err := r.Reserve(1)
+	if err != nil || d1 <= 0 || d1 > time.Millisecond*200 {
+		t.Fatalf("reserve: %s %v", d1, err)
+	}
+	// 归还后再次预约的等待时长不变
+	r.Cancel(1)
+	if d, err := r.Reserve(1); err != nil || d > d1 {
+		t.Fatalf("reserve after cancel: %s %s %v", d1, d, err)
+	}
+
+	d2, err := r.Reserve(1)
+	if err != nil || d2-d1 < time.Millisecond*150 {
+		t.Fatalf("reserve again: %s %s %v", d1, d2, err)
+	}
+
+	// 令牌补足后最多累积 concurrency 个
+	time.Sleep(d2 + time.Millisecond*600)
+	if !r.Allow() || !r.Allow() || r.Allow() {
+		t.Fatal("expect capacity 2")
+	}
+
+	if ttl := c.PTTL(ctx, key).Val(); ttl <= 0 {
+		t.Fatalf("ttl %s", ttl)
+	}
+
+	a, b := r.Tenant("a"), r.Tenant("b")
+	if !a.Allow() || a.Allow() || !b.Allow() {
+		t.Fatal("tenants should not share quota")
+	}
+}
+
+func TestRedisSlidingWindow(t *testing.T) {
+
+	c := testRedis(t)
+	ctx := context.Background()
+	key := testRedisKey(t, c)
+	r := NewRedis(ctx, c, key, time.Millisecond*300, 2, 0, SlidingWindow, WithoutFallback)
+
+	if !r.Allow() || !r.Allow() || r.Allow() {
+		t.Fatal("expect 2 per window")
+	}
+
+	// 窗口内最早的一次过期后才有额度
+	d, err := r.Reserve(1)
+	if err != nil || d <= 0 || d > time.Millisecond*300 {
+		t.Fatalf("reserve: %s %v", d, err)
+	}
+	if _, err = r.Reserve(3); err != ErrExceeded {
+		t.Fatalf("expect exceeded, got %v", err)
+	}
+
+	start := time.Now()
+	if err = r.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if w := time.Since(start); w < time.Millisecond*100 {
+		t.Fatalf("waited %s", w)
+	}
+
+	time.Sleep(time.Millisecond * 700)
+	if !r.Allow() || !r.Allow() || r.Allow() {
+		t.Fatal("expect window reset")
+	}
+}
+
+// ctx 结束时归还 WaitCtx 预约的令牌
+func TestRedisWaitCancel(t *testing.T) {
+
+	c := testRedis(t)
+	ctx := context.Background()
+
+	for _, window := range []bool{false, true} {
+
+		ops := []RedisOption{WithoutFallback}
+		if window {
+			ops = append(ops, SlidingWindow)
+		}
+		r := NewRedis(ctx, c, testRedisKey(t, c), time.Hour, 1, 1, ops...)
+
+		if !r.Allow() {
+			t.Fatalf("window %v: expect 1 token", window)
+		}
+
+		timeout, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+		err := r.WaitCtx(timeout)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Fatalf("window %v: wait %v", window, err)
+		}
+
+		// 已归还：再次预约的等待时长与第一次相同，而不是排在被取消的预约之后
+		d, err := r.Reserve(1)
+		if err != nil || d <= 0 || d > time.Hour {
+			t.Fatalf("window %v: reserve after cancel %s %v", window, d, err)
+		}
+		if window {
+			if n := c.ZCard(ctx, r.Key()).Val(); n != 2 {
+				t.Fatalf("window members %d", n)
+			}
+		} else if tokens := c.HGet(ctx, r.Key(), "tokens").Val(); tokens != "-1" {
+			t.Fatalf("tokens %s", tokens)
+		}
+	}
+}
+
+// 滑动窗口的 Cancel 只移除本实例记入的成员
+func TestRedisWindowCancelOwn(t *testing.T) {
+
+	c := testRedis(t)
+	ctx := context.Background()
+	key := testRedisKey(t, c)
+	a := NewRedis(ctx, c, key, time.Hour, 3, 0, SlidingWindow, WithoutFallback)
+	b := NewRedis(ctx, c, key, time.Hour, 3, 0, SlidingWindow, WithoutFallback)
+
+	if _, err := a.Reserve(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Reserve(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// b 最近记入了 2 个，a 归还时不应移除 b 的成员
+	a.Cancel(2)
+	for _, m := range b.reserved.list {
+		if err := c.ZScore(ctx, key, m.name).Err(); err != nil {
+			t.Fatalf("member %s of b: %v", m.name, err)
+		}
+	}
+	if n := c.ZCard(ctx, key).Val(); n != 2 {
+		t.Fatalf("members %d", n)
+	}
+
+	b.Cancel(1)
+	if n := c.ZCard(ctx, key).Val(); n != 1 {
+		t.Fatalf("members after b.Cancel %d", n)
+	}
+	if !a.Allow() || !a.Allow() || a.Allow() {
+		t.Fatal("expect 2 free slots")
+	}
+}
+
+// SetRate、SetBurst 同时调整已有的租户；闲置的租户被移除
+func TestRedisTenant(t *testing.T) {
+
+	c := testRedis(t)
+	ctx := context.Background()
+	r := NewRedis(ctx, c, testRedisKey(t, c), time.Hour, 1, 1, WithoutFallback, WithTenantIdle(time.Millisecond*50))
+
+	a := r.Tenant("a")
+	if a != r.Tenant("a") {
+		t.Fatal("expect cached tenant")
+	}
+
+	r.SetRate(time.Minute, 2)
+	r.SetBurst(3)
+	if d, assign, burst := a.rate(); d != time.Minute || assign != 2 || burst != 3 {
+		t.Fatalf("tenant rate %s %d %d", d, assign, burst)
+	}
+	if _, err := a.Reserve(3); err != nil {
+		t.Fatalf("reserve after SetBurst: %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	if n := r.tenants.Len(); n != 0 {
+		t.Fatalf("idle tenants %d", n)
+	}
+	if b := r.Tenant("a"); b == a {
+		t.Fatal("expect new tenant after idle")
+	}
+}
+
+// Stop 连同本地限速与租户一起停止
+func TestRedisStop(t *testing.T) {
+
+	c := testRedis(t)
+	ctx := context.Background()
+	r := NewRedis(ctx, c, testRedisKey(t, c), time.Hour, 1, 1)
+	a := r.Tenant("a")
+	a.Allow()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- a.Wait()
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	r.Stop().Stop()
+
+	for _, done := range []<-chan struct{}{r.Done(), a.Done(), r.local.Done(), a.local.Done(), r.Tenant("b").Done()} {
+		select {
+		case <-done:
+		default:
+			t.Fatal("expect stopped")
+		}
+	}
+
+	if err := <-errs; err != ErrStopped {
+		t.Fatalf("wait: %v", err)
+	}
+	if r.Allow() {
+		t.Fatal("expect deny after stop")
+	}
+	if _, err := r.Reserve(1); err != ErrStopped {
+		t.Fatalf("reserve: %v", err)
+	}
+}
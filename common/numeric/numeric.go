@@ -0,0 +1,56 @@
+package numeric
+
+import (
+	"github.com/jack0829/letsgo/common/types"
+)
+
+// Sum 求和
+func Sum[T types.Number](vs ...T) (r T) {
+	for _, v := range vs {
+		r += v
+	}
+	return
+}
+
+// Min 最小值，vs 为空时返回零值
+func Min[T types.Number](vs ...T) (r T) {
+	for i, v := range vs {
+		if i == 0 || v < r {
+			r = v
+		}
+	}
+	return
+}
+
+// Max 最大值，vs 为空时返回零值
+func Max[T types.Number](vs ...T) (r T) {
+	for i, v := range vs {
+		if i == 0 || v > r {
+			r = v
+		}
+	}
+	return
+}
+
+// Clamp 将 v 限制在 [lo, hi] 内
+func Clamp[T types.Number](v, lo, hi T) T {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Mean 算术平均，vs 为空时返回 0
+func Mean[T types.Number](vs ...T) float64 {
+	if len(vs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range vs {
+		sum += float64(v)
+	}
+	return sum / float64(len(vs))
+}
@@ -0,0 +1,84 @@
+package numeric
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNumeric(t *testing.T) {
+
+	if Sum(1, 2, 3) != 6 || Sum[float64]() != 0 {
+		t.Error("Sum")
+	}
+	if Min(3, -1, 2) != -1 || Max(uint8(3), 9, 2) != 9 || Min[int]() != 0 {
+		t.Error("Min/Max")
+	}
+	if Clamp(15, 0, 10) != 10 || Clamp(-1.5, 0, 1) != 0 || Clamp(5, 0, 10) != 5 {
+		t.Error("Clamp")
+	}
+	if Mean(1, 2, 3, 4) != 2.5 || Mean[int]() != 0 {
+		t.Error("Mean")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+
+	vs := []int{15, 20, 35, 40, 50}
+
+	r := Percentiles(vs, 0, 25, 50, 90, 100)
+	want := []float64{15, 20, 35, 46, 50}
+	for i := range want {
+		if math.Abs(r[i]-want[i]) > 1e-9 {
+			t.Fatalf("percentiles %v", r)
+		}
+	}
+
+	if !math.IsNaN(Percentile([]float64{}, 50)) {
+		t.Fatal("empty")
+	}
+
+	h := Histogram([]float64{0.1, 0.5, 0.5, 1, 3, 10}, []float64{0.5, 1, 5})
+	if len(h) != 4 || h[0] != 3 || h[1] != 1 || h[2] != 1 || h[3] != 1 {
+		t.Fatalf("histogram %v", h)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"int8 add", second(Add[int8](100, 27))},
+		{"int8 sub", second(Add[int8](-100, -28))},
+		{"uint8 mul", second(Mul[uint8](16, 15))},
+		{"int64 mul", second(Mul[int64](math.MaxInt64/2, -2))},
+	}
+	for _, c := range cases {
+		if c.err != nil {
+			t.Errorf("%s: %v", c.name, c.err)
+		}
+	}
+
+	overflow := []struct {
+		name string
+		err  error
+	}{
+		{"int8 add", second(Add[int8](100, 28))},
+		{"int8 sub", second(Add[int8](-100, -29))},
+		{"uint8 add", second(Add[uint8](200, 56))},
+		{"uint8 mul", second(Mul[uint8](16, 16))},
+		{"int64 mul", second(Mul[int64](math.MaxInt64/2+1, 2))},
+		{"min * -1", second(Mul[int64](math.MinInt64, -1))},
+		{"-1 * min", second(Mul[int64](-1, math.MinInt64))},
+	}
+	for _, c := range overflow {
+		if c.err != ErrOverflow {
+			t.Errorf("%s: expect overflow", c.name)
+		}
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
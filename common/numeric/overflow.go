@@ -0,0 +1,42 @@
+package numeric
+
+import (
+	"fmt"
+	"github.com/jack0829/letsgo/common/types"
+)
+
+var ErrOverflow = fmt.Errorf("numeric: 整数溢出")
+
+func signed[T types.Integer]() bool {
+	var zero T
+	return ^zero < 0
+}
+
+// Add 带溢出检查的整数加法
+func Add[T types.Integer](a, b T) (T, error) {
+	c := a + b
+	if (b > 0 && c < a) || (b < 0 && c > a) {
+		return c, ErrOverflow
+	}
+	return c, nil
+}
+
+// Mul 带溢出检查的整数乘法
+func Mul[T types.Integer](a, b T) (T, error) {
+
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+
+	c := a * b
+	if c/b != a {
+		return c, ErrOverflow
+	}
+
+	// MinInt * -1 时 c/b == a，需按符号判断
+	if signed[T]() && ((a < 0) != (b < 0)) != (c < 0) {
+		return c, ErrOverflow
+	}
+
+	return c, nil
+}
@@ -0,0 +1,47 @@
+package numeric
+
+import (
+	"github.com/jack0829/letsgo/common/types"
+	"math"
+	"slices"
+)
+
+// Percentile 第 p 百分位数（0 ~ 100），相邻样本间线性插值，vs 为空时返回 NaN
+func Percentile[T types.Number](vs []T, p float64) float64 {
+	return Percentiles(vs, p)[0]
+}
+
+// Percentiles 一次计算多个百分位数，只排序一次
+func Percentiles[T types.Number](vs []T, ps ...float64) []float64 {
+
+	r := make([]float64, len(ps))
+	if len(vs) == 0 {
+		for i := range r {
+			r[i] = math.NaN()
+		}
+		return r
+	}
+
+	sorted := slices.Clone(vs)
+	slices.Sort(sorted)
+
+	for i, p := range ps {
+		rank := Clamp(p, 0, 100) / 100 * float64(len(sorted)-1)
+		lo := int(math.Floor(rank))
+		hi := int(math.Ceil(rank))
+		r[i] = float64(sorted[lo]) + (float64(sorted[hi])-float64(sorted[lo]))*(rank-float64(lo))
+	}
+
+	return r
+}
+
+// Histogram 按升序的上界 bounds 统计各区间样本数
+// 第 i 个区间为 (bounds[i-1], bounds[i]]，最后一个为 (bounds[n-1], +Inf)，共 len(bounds)+1 个
+func Histogram[T types.Number](vs []T, bounds []T) []int {
+	counts := make([]int, len(bounds)+1)
+	for _, v := range vs {
+		i, _ := slices.BinarySearch(bounds, v)
+		counts[i]++
+	}
+	return counts
+}
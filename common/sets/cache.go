@@ -0,0 +1,512 @@
+package sets
+
+import (
+	"container/heap"
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Policy 缓存满时的淘汰策略
+type Policy uint8
+
+const (
+	LRU Policy = iota // 淘汰最久未访问的
+	LFU               // 淘汰访问次数最少的，次数相同时淘汰最久未访问的
+)
+
+// EvictReason 条目被移出缓存的原因
+type EvictReason uint8
+
+const (
+	Expired  EvictReason = iota // 过期
+	Capacity                    // 超出容量被淘汰
+	Removed                     // 被 Delete、Clear 或覆盖
+)
+
+// CacheStats 命中统计
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // 超出容量淘汰数
+	Expired   uint64 // 过期移除数
+}
+
+// HitRate 命中率，没有访问时为 0
+func (s CacheStats) HitRate() float64 {
+	if total := s.Hits + s.Misses; total > 0 {
+		return float64(s.Hits) / float64(total)
+	}
+	return 0
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key      K
+	value    V
+	expireAt time.Time // 零值表示不过期
+	freq     uint64
+	access   uint64 // 最近访问序号，LFU 同频时比较
+	elem     *list.Element
+	index    int
+}
+
+func (e *cacheEntry[K, V]) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+type cacheCall[V any] struct {
+	wg  sync.WaitGroup
+	v   V
+	err error
+}
+
+type evicted[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+// Cache 带过期时间与容量上限的缓存
+// 过期条目在访问时惰性清理，也可通过 WithJanitor 定期清理
+type Cache[K comparable, V any] struct {
+	mutex   sync.Mutex
+	data    map[K]*cacheEntry[K, V]
+	lru     list.List     // LRU：队首为最近访问
+	lfu     lfuHeap[K, V] // LFU：堆顶为最少访问
+	seq     uint64
+	stats   CacheStats
+	loading map[K]*cacheCall[V]
+	onEvict func(k K, v V, reason EvictReason)
+	cfg     cacheConfig
+	stop    context.CancelFunc
+}
+
+type cacheConfig struct {
+	maxSize  int
+	ttl      time.Duration
+	policy   Policy
+	ctx      context.Context
+	interval time.Duration
+}
+
+type CacheOption func(c *cacheConfig)
+
+// WithMaxSize 最多缓存条目数，0 表示不限制
+func WithMaxSize(n int) CacheOption {
+	return func(c *cacheConfig) {
+		c.maxSize = n
+	}
+}
+
+// WithTTL Set 时的默认有效期，0 表示不过期
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.ttl = ttl
+	}
+}
+
+// WithPolicy 淘汰策略，默认 LRU
+func WithPolicy(p Policy) CacheOption {
+	return func(c *cacheConfig) {
+		c.policy = p
+	}
+}
+
+// WithJanitor 每 interval 清理一次过期条目，ctx 结束或调用 Close 时停止
+func WithJanitor(ctx context.Context, interval time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.ctx = ctx
+		c.interval = interval
+	}
+}
+
+func NewCache[K comparable, V any](ops ...CacheOption) *Cache[K, V] {
+
+	c := &Cache[K, V]{
+		data:    make(map[K]*cacheEntry[K, V]),
+		loading: make(map[K]*cacheCall[V]),
+	}
+
+	for _, op := range ops {
+		op(&c.cfg)
+	}
+
+	if c.cfg.interval > 0 {
+		ctx := c.cfg.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, c.stop = context.WithCancel(ctx)
+		go c.janitor(ctx, c.cfg.interval)
+	}
+
+	return c
+}
+
+// OnEvict 条目被移出时回调，在锁外执行
+func (c *Cache[K, V]) OnEvict(fn func(k K, v V, reason EvictReason)) *Cache[K, V] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onEvict = fn
+	return c
+}
+
+// Close 停止后台清理
+func (c *Cache[K, V]) Close() {
+	if c.stop != nil {
+		c.stop()
+	}
+}
+
+func (c *Cache[K, V]) janitor(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Purge()
+		}
+	}
+}
+
+// Set 使用默认有效期写入
+func (c *Cache[K, V]) Set(k K, v V) (replaced bool) {
+	return c.SetTTL(k, v, c.cfg.ttl)
+}
+
+// SetTTL 指定有效期写入，ttl <= 0 表示不过期
+func (c *Cache[K, V]) SetTTL(k K, v V, ttl time.Duration) (replaced bool) {
+
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	return c.SetUntil(k, v, expireAt)
+}
+
+// SetUntil 指定过期时间写入，零值表示不过期
+func (c *Cache[K, V]) SetUntil(k K, v V, expireAt time.Time) (replaced bool) {
+
+	c.mutex.Lock()
+	var out []evicted[K, V]
+	replaced, out = c.set(k, v, expireAt)
+	fn := c.onEvict
+	c.mutex.Unlock()
+
+	c.notify(fn, out)
+	return
+}
+
+// set 需持有锁
+func (c *Cache[K, V]) set(k K, v V, expireAt time.Time) (replaced bool, out []evicted[K, V]) {
+
+	now := time.Now()
+	if e, ok := c.data[k]; ok {
+		if !e.expired(now) {
+			replaced = true
+			out = append(out, evicted[K, V]{e.key, e.value, Removed})
+		} else {
+			c.stats.Expired++
+			out = append(out, evicted[K, V]{e.key, e.value, Expired})
+		}
+		e.value, e.expireAt = v, expireAt
+		c.touch(e)
+		return
+	}
+
+	// 先淘汰再写入，避免 LFU 下新条目访问次数最少而被立即淘汰
+	for c.cfg.maxSize > 0 && len(c.data) >= c.cfg.maxSize {
+		victim := c.victim()
+		c.remove(victim)
+		if victim.expired(now) {
+			c.stats.Expired++
+			out = append(out, evicted[K, V]{victim.key, victim.value, Expired})
+		} else {
+			c.stats.Evictions++
+			out = append(out, evicted[K, V]{victim.key, victim.value, Capacity})
+		}
+	}
+
+	e := &cacheEntry[K, V]{
+		key:      k,
+		value:    v,
+		expireAt: expireAt,
+	}
+	c.data[k] = e
+	c.add(e)
+	return
+}
+
+// Get 读取未过期的条目
+func (c *Cache[K, V]) Get(k K) (v V, ok bool) {
+
+	c.mutex.Lock()
+	v, ok, out := c.get(k)
+	fn := c.onEvict
+	c.mutex.Unlock()
+
+	c.notify(fn, out)
+	return
+}
+
+// get 需持有锁
+func (c *Cache[K, V]) get(k K) (v V, ok bool, out []evicted[K, V]) {
+
+	e, exist := c.data[k]
+	if !exist {
+		c.stats.Misses++
+		return
+	}
+
+	if e.expired(time.Now()) {
+		c.remove(e)
+		c.stats.Misses++
+		c.stats.Expired++
+		out = append(out, evicted[K, V]{e.key, e.value, Expired})
+		return
+	}
+
+	c.stats.Hits++
+	c.touch(e)
+	return e.value, true, nil
+}
+
+// Peek 读取未过期的条目，不影响淘汰顺序与统计
+func (c *Cache[K, V]) Peek(k K) (v V, ok bool) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, exist := c.data[k]; exist && !e.expired(time.Now()) {
+		return e.value, true
+	}
+	return
+}
+
+// GetOrLoad 读取条目，不存在或已过期时调用 loader 计算并写入，ttl <= 0 时使用默认有效期
+// 同一 key 并发调用时只执行一次 loader；loader 出错时不写入
+func (c *Cache[K, V]) GetOrLoad(k K, loader func(k K) (v V, ttl time.Duration, err error)) (V, error) {
+
+	c.mutex.Lock()
+	v, ok, out := c.get(k)
+	if ok {
+		c.mutex.Unlock()
+		return v, nil
+	}
+
+	if call, loading := c.loading[k]; loading {
+		fn := c.onEvict
+		c.mutex.Unlock()
+		c.notify(fn, out)
+		call.wg.Wait()
+		return call.v, call.err
+	}
+
+	call := &cacheCall[V]{}
+	call.wg.Add(1)
+	c.loading[k] = call
+	fn := c.onEvict
+	c.mutex.Unlock()
+	c.notify(fn, out)
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.loading, k)
+		c.mutex.Unlock()
+		call.wg.Done()
+	}()
+
+	var ttl time.Duration
+	call.v, ttl, call.err = loader(k)
+	if call.err != nil {
+		return call.v, call.err
+	}
+
+	if ttl <= 0 {
+		ttl = c.cfg.ttl
+	}
+	c.SetTTL(k, call.v, ttl)
+	return call.v, nil
+}
+
+// Delete 删除条目
+func (c *Cache[K, V]) Delete(k K) (old V, ok bool) {
+
+	c.mutex.Lock()
+	e, exist := c.data[k]
+	if !exist {
+		c.mutex.Unlock()
+		return
+	}
+
+	c.remove(e)
+	fn := c.onEvict
+	c.mutex.Unlock()
+
+	if e.expired(time.Now()) {
+		return
+	}
+
+	c.notify(fn, []evicted[K, V]{{e.key, e.value, Removed}})
+	return e.value, true
+}
+
+// Clear 清空缓存，返回清除的条目数
+func (c *Cache[K, V]) Clear() (cnt int) {
+
+	c.mutex.Lock()
+	out := make([]evicted[K, V], 0, len(c.data))
+	for _, e := range c.data {
+		out = append(out, evicted[K, V]{e.key, e.value, Removed})
+	}
+	c.data = make(map[K]*cacheEntry[K, V])
+	c.lru.Init()
+	c.lfu = nil
+	fn := c.onEvict
+	c.mutex.Unlock()
+
+	c.notify(fn, out)
+	return len(out)
+}
+
+// Purge 立即清理过期条目，返回清理数
+func (c *Cache[K, V]) Purge() (cnt int) {
+
+	now := time.Now()
+
+	c.mutex.Lock()
+	var out []evicted[K, V]
+	for _, e := range c.data {
+		if e.expired(now) {
+			c.remove(e)
+			c.stats.Expired++
+			out = append(out, evicted[K, V]{e.key, e.value, Expired})
+		}
+	}
+	fn := c.onEvict
+	c.mutex.Unlock()
+
+	c.notify(fn, out)
+	return len(out)
+}
+
+// Len 条目数，可能包含尚未清理的过期条目
+func (c *Cache[K, V]) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.data)
+}
+
+// Stats 命中统计
+func (c *Cache[K, V]) Stats() CacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stats
+}
+
+// Each 遍历未过期的条目，不影响淘汰顺序
+func (c *Cache[K, V]) Each(handler func(k K, v V)) {
+
+	now := time.Now()
+
+	c.mutex.Lock()
+	entries := make([]*cacheEntry[K, V], 0, len(c.data))
+	for _, e := range c.data {
+		if !e.expired(now) {
+			entries = append(entries, e)
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, e := range entries {
+		handler(e.key, e.value)
+	}
+}
+
+func (c *Cache[K, V]) notify(fn func(k K, v V, reason EvictReason), out []evicted[K, V]) {
+	if fn == nil {
+		return
+	}
+	for _, e := range out {
+		fn(e.key, e.value, e.reason)
+	}
+}
+
+// 以下需持有锁
+
+func (c *Cache[K, V]) add(e *cacheEntry[K, V]) {
+	c.seq++
+	e.freq, e.access = 1, c.seq
+	if c.cfg.policy == LFU {
+		heap.Push(&c.lfu, e)
+		return
+	}
+	e.elem = c.lru.PushFront(e)
+}
+
+func (c *Cache[K, V]) touch(e *cacheEntry[K, V]) {
+	c.seq++
+	e.freq++
+	e.access = c.seq
+	if c.cfg.policy == LFU {
+		heap.Fix(&c.lfu, e.index)
+		return
+	}
+	c.lru.MoveToFront(e.elem)
+}
+
+func (c *Cache[K, V]) remove(e *cacheEntry[K, V]) {
+	delete(c.data, e.key)
+	if c.cfg.policy == LFU {
+		heap.Remove(&c.lfu, e.index)
+		return
+	}
+	c.lru.Remove(e.elem)
+}
+
+// victim 待淘汰的条目
+func (c *Cache[K, V]) victim() *cacheEntry[K, V] {
+	if c.cfg.policy == LFU {
+		return c.lfu[0]
+	}
+	return c.lru.Back().Value.(*cacheEntry[K, V])
+}
+
+type lfuHeap[K comparable, V any] []*cacheEntry[K, V]
+
+func (h lfuHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].access < h[j].access
+}
+
+func (h lfuHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+
+func (h *lfuHeap[K, V]) Push(x any) {
+	e := x.(*cacheEntry[K, V])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
@@ -0,0 +1,151 @@
+package sets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+
+	c := NewCache[string, int](WithTTL(time.Millisecond * 20))
+
+	var reasons []EvictReason
+	c.OnEvict(func(k string, v int, reason EvictReason) {
+		reasons = append(reasons, reason)
+	})
+
+	c.Set("a", 1)
+	c.SetTTL("b", 2, 0)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("get a: %d %v", v, ok)
+	}
+
+	time.Sleep(time.Millisecond * 30)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("a should expire")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Fatal("b should not expire")
+	}
+
+	s := c.Stats()
+	if s.Hits != 2 || s.Misses != 1 || s.Expired != 1 {
+		t.Fatalf("stats %+v", s)
+	}
+	if len(reasons) != 1 || reasons[0] != Expired {
+		t.Fatalf("reasons %v", reasons)
+	}
+}
+
+func TestCacheLRU(t *testing.T) {
+
+	c := NewCache[int, int](WithMaxSize(3))
+
+	var evicted []int
+	c.OnEvict(func(k, v int, reason EvictReason) {
+		if reason == Capacity {
+			evicted = append(evicted, k)
+		}
+	})
+
+	for i := 0; i < 3; i++ {
+		c.Set(i, i)
+	}
+	c.Get(0)
+	c.Set(3, 3) // 淘汰 1
+	c.Get(2)
+	c.Set(4, 4) // 淘汰 0
+
+	if fmt.Sprint(evicted) != "[1 0]" || c.Len() != 3 {
+		t.Fatalf("evicted %v len %d", evicted, c.Len())
+	}
+}
+
+func TestCacheLFU(t *testing.T) {
+
+	c := NewCache[int, int](WithMaxSize(3), WithPolicy(LFU))
+
+	for i := 0; i < 3; i++ {
+		c.Set(i, i)
+	}
+	c.Get(0)
+	c.Get(0)
+	c.Get(1)
+	c.Get(2)
+	c.Set(3, 3) // 1、2 同频，淘汰较早访问的 1
+
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("1 should be evicted")
+	}
+
+	c.Get(3)
+	c.Get(3)
+	c.Set(4, 4) // 淘汰 2
+	for k, want := range map[int]bool{0: true, 2: false, 3: true, 4: true} {
+		if _, ok := c.Peek(k); ok != want {
+			t.Fatalf("peek %d: %v", k, ok)
+		}
+	}
+
+	if s := c.Stats(); s.Evictions != 2 {
+		t.Fatalf("stats %+v", s)
+	}
+}
+
+func TestCacheJanitor(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewCache[string, string](WithJanitor(ctx, time.Millisecond*10))
+	defer c.Close()
+
+	c.SetTTL("k", "v", time.Millisecond*5)
+	time.Sleep(time.Millisecond * 50)
+
+	if c.Len() != 0 {
+		t.Fatalf("len %d", c.Len())
+	}
+}
+
+func TestCacheGetOrLoad(t *testing.T) {
+
+	c := NewCache[string, int]()
+
+	var calls int32
+	loader := func(k string) (int, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond * 10)
+		if k == "bad" {
+			return 0, 0, fmt.Errorf("bad key")
+		}
+		return len(k), time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.GetOrLoad("abc", loader); err != nil || v != 3 {
+				t.Errorf("load: %d %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("loader called %d times", calls)
+	}
+
+	if _, err := c.GetOrLoad("bad", loader); err == nil {
+		t.Fatal("expect error")
+	}
+	if _, ok := c.Peek("bad"); ok {
+		t.Fatal("error result cached")
+	}
+}
@@ -0,0 +1,167 @@
+package sets
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+)
+
+// HashSet 无序集合，非并发安全
+// JSON、YAML 中表示为数组
+type HashSet[T comparable] map[T]struct{}
+
+func NewHashSet[T comparable](items ...T) HashSet[T] {
+	s := make(HashSet[T], len(items))
+	s.Add(items...)
+	return s
+}
+
+func (s HashSet[T]) Add(items ...T) {
+	for _, v := range items {
+		s[v] = struct{}{}
+	}
+}
+
+func (s HashSet[T]) Remove(items ...T) {
+	for _, v := range items {
+		delete(s, v)
+	}
+}
+
+func (s HashSet[T]) Has(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// HasAny 包含任意一个，items 为空时返回 false
+func (s HashSet[T]) HasAny(items ...T) bool {
+	for _, v := range items {
+		if s.Has(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAll 包含全部，items 为空时返回 true
+func (s HashSet[T]) HasAll(items ...T) bool {
+	for _, v := range items {
+		if !s.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s HashSet[T]) Len() int {
+	return len(s)
+}
+
+// Items 全部元素，顺序不固定
+func (s HashSet[T]) Items() []T {
+	items := make([]T, 0, len(s))
+	for v := range s {
+		items = append(items, v)
+	}
+	return items
+}
+
+func (s HashSet[T]) Clone() HashSet[T] {
+	c := make(HashSet[T], len(s))
+	for v := range s {
+		c[v] = struct{}{}
+	}
+	return c
+}
+
+// Union 并集
+func (s HashSet[T]) Union(o HashSet[T]) HashSet[T] {
+	c := s.Clone()
+	for v := range o {
+		c[v] = struct{}{}
+	}
+	return c
+}
+
+// Intersect 交集
+func (s HashSet[T]) Intersect(o HashSet[T]) HashSet[T] {
+	if len(s) > len(o) {
+		s, o = o, s
+	}
+	c := make(HashSet[T])
+	for v := range s {
+		if o.Has(v) {
+			c[v] = struct{}{}
+		}
+	}
+	return c
+}
+
+// Difference 差集：在 s 中但不在 o 中
+func (s HashSet[T]) Difference(o HashSet[T]) HashSet[T] {
+	c := make(HashSet[T])
+	for v := range s {
+		if !o.Has(v) {
+			c[v] = struct{}{}
+		}
+	}
+	return c
+}
+
+// SymmetricDifference 对称差：只在其中一个集合中
+func (s HashSet[T]) SymmetricDifference(o HashSet[T]) HashSet[T] {
+	c := s.Difference(o)
+	for v := range o {
+		if !s.Has(v) {
+			c[v] = struct{}{}
+		}
+	}
+	return c
+}
+
+// IsSubset s 是 o 的子集
+func (s HashSet[T]) IsSubset(o HashSet[T]) bool {
+	if len(s) > len(o) {
+		return false
+	}
+	for v := range s {
+		if !o.Has(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset s 是 o 的超集
+func (s HashSet[T]) IsSuperset(o HashSet[T]) bool {
+	return o.IsSubset(s)
+}
+
+func (s HashSet[T]) Equal(o HashSet[T]) bool {
+	return len(s) == len(o) && s.IsSubset(o)
+}
+
+func (s HashSet[T]) MarshalJSON() ([]byte, error) {
+	return jsoniter.Marshal(s.Items())
+}
+
+func (s *HashSet[T]) UnmarshalJSON(b []byte) error {
+	var items []T
+	if err := jsoniter.Unmarshal(b, &items); err != nil {
+		return err
+	}
+	*s = NewHashSet(items...)
+	return nil
+}
+
+func (s HashSet[T]) MarshalYAML() (any, error) {
+	return s.Items(), nil
+}
+
+func (s *HashSet[T]) UnmarshalYAML(node *yaml.Node) error {
+	var items []T
+	if err := node.Decode(&items); err != nil {
+		return err
+	}
+	*s = NewHashSet(items...)
+	return nil
+}
@@ -0,0 +1,78 @@
+package sets
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v3"
+	"slices"
+	"testing"
+)
+
+func sorted(s HashSet[int]) []int {
+	items := s.Items()
+	slices.Sort(items)
+	return items
+}
+
+func TestHashSet(t *testing.T) {
+
+	a := NewHashSet(1, 2, 3, 4)
+	b := NewHashSet(3, 4, 5)
+
+	cases := []struct {
+		name string
+		got  HashSet[int]
+		want []int
+	}{
+		{"union", a.Union(b), []int{1, 2, 3, 4, 5}},
+		{"intersect", a.Intersect(b), []int{3, 4}},
+		{"difference", a.Difference(b), []int{1, 2}},
+		{"symmetric", a.SymmetricDifference(b), []int{1, 2, 5}},
+	}
+	for _, c := range cases {
+		if got := sorted(c.got); !slices.Equal(got, c.want) {
+			t.Errorf("%s: %v", c.name, got)
+		}
+	}
+
+	if !NewHashSet(3, 4).IsSubset(a) || b.IsSubset(a) || !a.IsSuperset(NewHashSet(1)) {
+		t.Error("subset")
+	}
+	if !a.HasAny(9, 1) || a.HasAny() || !a.HasAll() || a.HasAll(1, 9) {
+		t.Error("has")
+	}
+	if !a.Equal(NewHashSet(4, 3, 2, 1)) || a.Equal(b) {
+		t.Error("equal")
+	}
+}
+
+func TestHashSetMarshal(t *testing.T) {
+
+	var v struct {
+		Roles HashSet[int] `json:"roles" yaml:"roles"`
+	}
+
+	if err := jsoniter.UnmarshalFromString(`{"roles":[1,2,2,3]}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	if got := sorted(v.Roles); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("json: %v", got)
+	}
+
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v.Roles = nil
+	if err = yaml.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+	if got := sorted(v.Roles); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Fatalf("yaml: %v", got)
+	}
+
+	s, _ := jsoniter.MarshalToString(NewHashSet("a"))
+	if s != `["a"]` {
+		t.Fatalf("marshal %s", s)
+	}
+}
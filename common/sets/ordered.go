@@ -0,0 +1,296 @@
+package sets
+
+import (
+	"cmp"
+	"math/rand/v2"
+)
+
+const skipMaxLevel = 32
+
+type skipNode[K any, V any] struct {
+	key   K
+	value V
+	next  []*skipNode[K, V]
+	prev  *skipNode[K, V]
+}
+
+// OrderedMap 按 key 排序的 Map，基于跳表，非并发安全
+type OrderedMap[K any, V any] struct {
+	head  skipNode[K, V]
+	tail  *skipNode[K, V]
+	level int
+	len   int
+	cmp   func(a, b K) int
+}
+
+// NewOrderedMap 使用 cmp 比较 key，cmp 返回值含义同 cmp.Compare
+func NewOrderedMap[K any, V any](cmp func(a, b K) int) *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		head:  skipNode[K, V]{next: make([]*skipNode[K, V], skipMaxLevel)},
+		level: 1,
+		cmp:   cmp,
+	}
+}
+
+// NewOrdered 按 key 自然顺序排序的 OrderedMap
+func NewOrdered[K cmp.Ordered, V any]() *OrderedMap[K, V] {
+	return NewOrderedMap[K, V](cmp.Compare[K])
+}
+
+func (m *OrderedMap[K, V]) randomLevel() int {
+	l := 1
+	for l < skipMaxLevel && rand.Uint32()&3 == 0 {
+		l++
+	}
+	return l
+}
+
+// seek 返回每层最后一个小于 k 的节点
+func (m *OrderedMap[K, V]) seek(k K, update []*skipNode[K, V]) *skipNode[K, V] {
+	x := &m.head
+	for i := m.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && m.cmp(x.next[i].key, k) < 0 {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x
+}
+
+// ceiling 第一个 >= k 的节点
+func (m *OrderedMap[K, V]) ceiling(k K) *skipNode[K, V] {
+	return m.seek(k, nil).next[0]
+}
+
+func (m *OrderedMap[K, V]) Set(k K, v V) (replaced bool) {
+
+	var update [skipMaxLevel]*skipNode[K, V]
+	x := m.seek(k, update[:]).next[0]
+	if x != nil && m.cmp(x.key, k) == 0 {
+		x.value = v
+		return true
+	}
+
+	l := m.randomLevel()
+	if l > m.level {
+		for i := m.level; i < l; i++ {
+			update[i] = &m.head
+		}
+		m.level = l
+	}
+
+	x = &skipNode[K, V]{
+		key:   k,
+		value: v,
+		next:  make([]*skipNode[K, V], l),
+	}
+	for i := 0; i < l; i++ {
+		x.next[i] = update[i].next[i]
+		update[i].next[i] = x
+	}
+
+	if update[0] != &m.head {
+		x.prev = update[0]
+	}
+	if x.next[0] != nil {
+		x.next[0].prev = x
+	} else {
+		m.tail = x
+	}
+
+	m.len++
+	return false
+}
+
+func (m *OrderedMap[K, V]) Get(k K) (v V, ok bool) {
+	if x := m.ceiling(k); x != nil && m.cmp(x.key, k) == 0 {
+		return x.value, true
+	}
+	return
+}
+
+func (m *OrderedMap[K, V]) Has(k K) bool {
+	_, ok := m.Get(k)
+	return ok
+}
+
+func (m *OrderedMap[K, V]) Delete(k K) (old V, ok bool) {
+
+	var update [skipMaxLevel]*skipNode[K, V]
+	x := m.seek(k, update[:]).next[0]
+	if x == nil || m.cmp(x.key, k) != 0 {
+		return
+	}
+
+	for i := 0; i < len(x.next); i++ {
+		update[i].next[i] = x.next[i]
+	}
+	if x.next[0] != nil {
+		x.next[0].prev = x.prev
+	} else {
+		m.tail = x.prev
+	}
+
+	for m.level > 1 && m.head.next[m.level-1] == nil {
+		m.level--
+	}
+
+	m.len--
+	return x.value, true
+}
+
+func (m *OrderedMap[K, V]) Len() int {
+	return m.len
+}
+
+// Min 最小的 key
+func (m *OrderedMap[K, V]) Min() (k K, v V, ok bool) {
+	if x := m.head.next[0]; x != nil {
+		return x.key, x.value, true
+	}
+	return
+}
+
+// Max 最大的 key
+func (m *OrderedMap[K, V]) Max() (k K, v V, ok bool) {
+	if x := m.tail; x != nil {
+		return x.key, x.value, true
+	}
+	return
+}
+
+// Ceiling 第一个 >= k 的 key
+func (m *OrderedMap[K, V]) Ceiling(k K) (key K, v V, ok bool) {
+	if x := m.ceiling(k); x != nil {
+		return x.key, x.value, true
+	}
+	return
+}
+
+// Floor 最后一个 <= k 的 key
+func (m *OrderedMap[K, V]) Floor(k K) (key K, v V, ok bool) {
+	x := m.ceiling(k)
+	switch {
+	case x != nil && m.cmp(x.key, k) == 0:
+	case x != nil:
+		x = x.prev
+	default:
+		x = m.tail
+	}
+	if x != nil {
+		return x.key, x.value, true
+	}
+	return
+}
+
+// Range 按升序遍历 [from, to) 内的条目，handler 返回 false 时停止
+func (m *OrderedMap[K, V]) Range(from, to K, handler func(k K, v V) bool) {
+	for x := m.ceiling(from); x != nil && m.cmp(x.key, to) < 0; x = x.next[0] {
+		if !handler(x.key, x.value) {
+			return
+		}
+	}
+}
+
+// Each 按升序遍历，handler 返回 false 时停止
+func (m *OrderedMap[K, V]) Each(handler func(k K, v V) bool) {
+	for x := m.head.next[0]; x != nil; x = x.next[0] {
+		if !handler(x.key, x.value) {
+			return
+		}
+	}
+}
+
+// Reverse 按降序遍历，handler 返回 false 时停止
+func (m *OrderedMap[K, V]) Reverse(handler func(k K, v V) bool) {
+	for x := m.tail; x != nil; x = x.prev {
+		if !handler(x.key, x.value) {
+			return
+		}
+	}
+}
+
+// Keys 升序的全部 key
+func (m *OrderedMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.len)
+	for x := m.head.next[0]; x != nil; x = x.next[0] {
+		keys = append(keys, x.key)
+	}
+	return keys
+}
+
+// OrderedSet 有序集合，非并发安全
+type OrderedSet[T any] struct {
+	m *OrderedMap[T, struct{}]
+}
+
+// NewOrderedSetFunc 使用 cmp 比较元素
+func NewOrderedSetFunc[T any](cmp func(a, b T) int, items ...T) *OrderedSet[T] {
+	s := &OrderedSet[T]{m: NewOrderedMap[T, struct{}](cmp)}
+	s.Add(items...)
+	return s
+}
+
+// NewOrderedSet 按自然顺序排序的有序集合
+func NewOrderedSet[T cmp.Ordered](items ...T) *OrderedSet[T] {
+	return NewOrderedSetFunc(cmp.Compare[T], items...)
+}
+
+func (s *OrderedSet[T]) Add(items ...T) {
+	for _, v := range items {
+		s.m.Set(v, struct{}{})
+	}
+}
+
+func (s *OrderedSet[T]) Remove(items ...T) {
+	for _, v := range items {
+		s.m.Delete(v)
+	}
+}
+
+func (s *OrderedSet[T]) Has(v T) bool {
+	return s.m.Has(v)
+}
+
+func (s *OrderedSet[T]) Len() int {
+	return s.m.Len()
+}
+
+func (s *OrderedSet[T]) Min() (v T, ok bool) {
+	v, _, ok = s.m.Min()
+	return
+}
+
+func (s *OrderedSet[T]) Max() (v T, ok bool) {
+	v, _, ok = s.m.Max()
+	return
+}
+
+// Ceiling 第一个 >= v 的元素
+func (s *OrderedSet[T]) Ceiling(v T) (c T, ok bool) {
+	c, _, ok = s.m.Ceiling(v)
+	return
+}
+
+// Floor 最后一个 <= v 的元素
+func (s *OrderedSet[T]) Floor(v T) (f T, ok bool) {
+	f, _, ok = s.m.Floor(v)
+	return
+}
+
+// Range [from, to) 内的元素，升序
+func (s *OrderedSet[T]) Range(from, to T) []T {
+	var items []T
+	s.m.Range(from, to, func(k T, _ struct{}) bool {
+		items = append(items, k)
+		return true
+	})
+	return items
+}
+
+// Items 升序的全部元素
+func (s *OrderedSet[T]) Items() []T {
+	return s.m.Keys()
+}
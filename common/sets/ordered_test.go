@@ -0,0 +1,90 @@
+package sets
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOrderedMap(t *testing.T) {
+
+	m := NewOrdered[int, string]()
+	want := make(map[int]string)
+
+	for _, i := range rand.Perm(1000) {
+		m.Set(i, "v")
+		want[i] = "v"
+	}
+	for i := 0; i < 1000; i += 3 {
+		if _, ok := m.Delete(i); !ok {
+			t.Fatalf("delete %d", i)
+		}
+		delete(want, i)
+	}
+
+	if m.Len() != len(want) {
+		t.Fatalf("len %d want %d", m.Len(), len(want))
+	}
+
+	keys := m.Keys()
+	if !slices.IsSorted(keys) || len(keys) != len(want) {
+		t.Fatal("keys not sorted")
+	}
+
+	var rev []int
+	m.Reverse(func(k int, _ string) bool {
+		rev = append(rev, k)
+		return true
+	})
+	slices.Reverse(rev)
+	if !slices.Equal(rev, keys) {
+		t.Fatal("reverse")
+	}
+
+	if k, _, _ := m.Min(); k != 1 {
+		t.Fatalf("min %d", k)
+	}
+	if k, _, _ := m.Max(); k != 998 {
+		t.Fatalf("max %d", k)
+	}
+	if k, _, _ := m.Floor(3); k != 2 {
+		t.Fatalf("floor %d", k)
+	}
+	if k, _, _ := m.Ceiling(3); k != 4 {
+		t.Fatalf("ceiling %d", k)
+	}
+	if _, _, ok := m.Floor(0); ok {
+		t.Fatal("floor 0")
+	}
+
+	var r []int
+	m.Range(10, 20, func(k int, _ string) bool {
+		r = append(r, k)
+		return true
+	})
+	if !slices.Equal(r, []int{10, 11, 13, 14, 16, 17, 19}) {
+		t.Fatalf("range %v", r)
+	}
+}
+
+func TestOrderedSet(t *testing.T) {
+
+	s := NewOrderedSetFunc(strings.Compare, "c", "a", "b", "a")
+	if !slices.Equal(s.Items(), []string{"a", "b", "c"}) {
+		t.Fatalf("items %v", s.Items())
+	}
+
+	s.Remove("b")
+	if s.Has("b") || s.Len() != 2 {
+		t.Fatal("remove")
+	}
+
+	n := NewOrderedSet(5, 1, 9, 3)
+	if got := n.Range(2, 9); !slices.Equal(got, []int{3, 5}) {
+		t.Fatalf("range %v", got)
+	}
+	if v, _ := n.Floor(4); v != 3 {
+		t.Fatalf("floor %d", v)
+	}
+}
@@ -0,0 +1,102 @@
+package sets
+
+import (
+	"hash/maphash"
+)
+
+const defaultShards = 32
+
+// Sharded 分片的并发 Map，方法与 Set 相同
+// key 按哈希分散到多个 Set，写锁与 Each 的复制只作用于单个分片
+type Sharded[K comparable, V any] struct {
+	shards []Set[K, V]
+	mask   uint64
+	hash   func(k K) uint64
+}
+
+// NewSharded shards 为分片数，向上取整为 2 的幂，<= 0 时默认 32
+// hash 为空时使用 maphash
+func NewSharded[K comparable, V any](shards int, hash func(k K) uint64) *Sharded[K, V] {
+
+	if shards <= 0 {
+		shards = defaultShards
+	}
+
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+
+	if hash == nil {
+		seed := maphash.MakeSeed()
+		hash = func(k K) uint64 {
+			return maphash.Comparable(seed, k)
+		}
+	}
+
+	return &Sharded[K, V]{
+		shards: make([]Set[K, V], n),
+		mask:   uint64(n - 1),
+		hash:   hash,
+	}
+}
+
+func (s *Sharded[K, V]) shard(k K) *Set[K, V] {
+	return &s.shards[s.hash(k)&s.mask]
+}
+
+func (s *Sharded[K, V]) Set(k K, v V) (replaced bool) {
+	return s.shard(k).Set(k, v)
+}
+
+func (s *Sharded[K, V]) SetFn(k K, fn func(k K, v V, exist bool) (nv V, op Operator)) (nv V, op Operator) {
+	return s.shard(k).SetFn(k, fn)
+}
+
+func (s *Sharded[K, V]) SetX(k K, v V) (old V, ok bool) {
+	return s.shard(k).SetX(k, v)
+}
+
+func (s *Sharded[K, V]) SetNX(k K, v V) (ok bool) {
+	return s.shard(k).SetNX(k, v)
+}
+
+func (s *Sharded[K, V]) Get(k K) (v V, ok bool) {
+	return s.shard(k).Get(k)
+}
+
+func (s *Sharded[K, V]) Delete(k K) (old V, ok bool) {
+	return s.shard(k).Delete(k)
+}
+
+func (s *Sharded[K, V]) Clear() (cnt int) {
+	for i := range s.shards {
+		cnt += s.shards[i].Clear()
+	}
+	return
+}
+
+// Len 各分片条目数之和，并发写入时只是近似值
+func (s *Sharded[K, V]) Len() (cnt int) {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.x.RLock()
+		cnt += len(sh.data)
+		sh.x.RUnlock()
+	}
+	return
+}
+
+// Each 逐个分片遍历，不是全局一致的快照
+func (s *Sharded[K, V]) Each(handler func(k K, v V)) {
+	for i := range s.shards {
+		s.shards[i].Each(handler)
+	}
+}
+
+// EachForWrite 逐个分片持写锁遍历
+func (s *Sharded[K, V]) EachForWrite(handler func(k K, v V)) {
+	for i := range s.shards {
+		s.shards[i].EachForWrite(handler)
+	}
+}
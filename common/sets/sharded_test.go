@@ -0,0 +1,149 @@
+package sets
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSharded(t *testing.T) {
+
+	s := NewSharded[string, int](10, nil)
+	if len(s.shards) != 16 {
+		t.Fatalf("shards %d", len(s.shards))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				s.Set(strconv.Itoa(i*100+j), j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if s.Len() != 800 {
+		t.Fatalf("len %d", s.Len())
+	}
+
+	if !s.SetNX("new", 1) || s.SetNX("new", 2) {
+		t.Fatal("SetNX")
+	}
+	if old, ok := s.SetX("new", 3); !ok || old != 1 {
+		t.Fatalf("SetX %d %v", old, ok)
+	}
+	if _, ok := s.SetX("none", 1); ok {
+		t.Fatal("SetX none")
+	}
+
+	s.SetFn("new", func(_ string, v int, exist bool) (int, Operator) {
+		return 0, Delete
+	})
+	if _, ok := s.Get("new"); ok {
+		t.Fatal("SetFn delete")
+	}
+
+	sum := 0
+	s.Each(func(_ string, v int) {
+		sum += v
+	})
+	if sum != 8*4950 {
+		t.Fatalf("sum %d", sum)
+	}
+
+	if n := s.Clear(); n != 800 || s.Len() != 0 {
+		t.Fatalf("clear %d len %d", n, s.Len())
+	}
+}
+
+func TestShardedHash(t *testing.T) {
+
+	// 自定义哈希全部落在同一分片
+	s := NewSharded[int, int](4, func(int) uint64 { return 0 })
+	for i := 0; i < 10; i++ {
+		s.Set(i, i)
+	}
+
+	if len(s.shards[0].data) != 10 {
+		t.Fatalf("shard 0 len %d", len(s.shards[0].data))
+	}
+}
+
+const benchKeys = 1 << 12
+
+func benchKeyList() []string {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = "session:" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+type benchMap interface {
+	Set(k string, v int) bool
+	Get(k string) (int, bool)
+}
+
+// benchMixed 每 writeEvery 次操作中有一次写入
+func benchMixed(b *testing.B, m benchMap, writeEvery int) {
+
+	keys := benchKeyList()
+	for i, k := range keys {
+		m.Set(k, i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			k := keys[i&(benchKeys-1)]
+			if i%writeEvery == 0 {
+				m.Set(k, i)
+			} else {
+				m.Get(k)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSetRead(b *testing.B) {
+	benchMixed(b, &Set[string, int]{}, 1000)
+}
+
+func BenchmarkShardedRead(b *testing.B) {
+	benchMixed(b, NewSharded[string, int](0, nil), 1000)
+}
+
+func BenchmarkSetMixed(b *testing.B) {
+	benchMixed(b, &Set[string, int]{}, 4)
+}
+
+func BenchmarkShardedMixed(b *testing.B) {
+	benchMixed(b, NewSharded[string, int](0, nil), 4)
+}
+
+func BenchmarkSetEach(b *testing.B) {
+	s := &Set[string, int]{}
+	for i, k := range benchKeyList() {
+		s.Set(k, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Each(func(string, int) {})
+	}
+}
+
+func BenchmarkShardedEach(b *testing.B) {
+	s := NewSharded[string, int](0, nil)
+	for i, k := range benchKeyList() {
+		s.Set(k, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Each(func(string, int) {})
+	}
+}
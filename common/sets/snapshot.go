@@ -0,0 +1,191 @@
+package sets
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/common/fs"
+	jsoniter "github.com/json-iterator/go"
+	"io"
+	"os"
+	"time"
+)
+
+// Codec 快照编码
+type Codec uint8
+
+const (
+	JSON Codec = iota // key、value 需可 JSON 序列化
+	Gob               // key、value 需可 gob 序列化，接口类型需先 gob.Register
+)
+
+type snapshotEntry[K comparable, V any] struct {
+	Key      K         `json:"k"`
+	Value    V         `json:"v"`
+	ExpireAt time.Time `json:"e,omitempty"` // 仅 Cache 使用
+}
+
+func encodeSnapshot(w io.Writer, codec Codec, v any) error {
+	switch codec {
+	case JSON:
+		return jsoniter.NewEncoder(w).Encode(v)
+	case Gob:
+		return gob.NewEncoder(w).Encode(v)
+	default:
+		return fmt.Errorf("sets: 未知的快照编码 %d", codec)
+	}
+}
+
+func decodeSnapshot(r io.Reader, codec Codec, v any) error {
+	switch codec {
+	case JSON:
+		return jsoniter.NewDecoder(r).Decode(v)
+	case Gob:
+		return gob.NewDecoder(r).Decode(v)
+	default:
+		return fmt.Errorf("sets: 未知的快照编码 %d", codec)
+	}
+}
+
+// loadFile 文件不存在时不调用 read
+func loadFile(path string, read func(r io.Reader) error) error {
+
+	fp, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	return read(fp)
+}
+
+// Snapshot 将全部条目写入 w
+func (s *Set[K, V]) Snapshot(w io.Writer, codec Codec) error {
+
+	s.x.RLock()
+	list := make([]snapshotEntry[K, V], 0, len(s.data))
+	for k, v := range s.data {
+		list = append(list, snapshotEntry[K, V]{Key: k, Value: v})
+	}
+	s.x.RUnlock()
+
+	return encodeSnapshot(w, codec, list)
+}
+
+// Restore 从 r 读取快照写入，已存在的 key 被覆盖，返回读取的条目数
+func (s *Set[K, V]) Restore(r io.Reader, codec Codec) (cnt int, err error) {
+
+	var list []snapshotEntry[K, V]
+	if err = decodeSnapshot(r, codec, &list); err != nil {
+		return
+	}
+
+	s.x.Lock()
+	defer s.x.Unlock()
+	s.init()
+
+	for _, e := range list {
+		s.data[e.Key] = e.Value
+	}
+	return len(list), nil
+}
+
+// SaveFile 快照原子写入文件
+func (s *Set[K, V]) SaveFile(path string, codec Codec) error {
+	return fs.WriteFileFunc(path, 0644, func(w io.Writer) error {
+		return s.Snapshot(w, codec)
+	})
+}
+
+// LoadFile 从文件恢复，文件不存在时返回 0
+func (s *Set[K, V]) LoadFile(path string, codec Codec) (cnt int, err error) {
+	err = loadFile(path, func(r io.Reader) error {
+		cnt, err = s.Restore(r, codec)
+		return err
+	})
+	return
+}
+
+// Snapshot 将未过期的条目连同过期时间写入 w
+func (c *Cache[K, V]) Snapshot(w io.Writer, codec Codec) error {
+
+	now := time.Now()
+
+	c.mutex.Lock()
+	list := make([]snapshotEntry[K, V], 0, len(c.data))
+	for _, e := range c.data {
+		if !e.expired(now) {
+			list = append(list, snapshotEntry[K, V]{Key: e.key, Value: e.value, ExpireAt: e.expireAt})
+		}
+	}
+	c.mutex.Unlock()
+
+	return encodeSnapshot(w, codec, list)
+}
+
+// Restore 从 r 读取快照写入，跳过已过期的条目，返回写入的条目数
+func (c *Cache[K, V]) Restore(r io.Reader, codec Codec) (cnt int, err error) {
+
+	var list []snapshotEntry[K, V]
+	if err = decodeSnapshot(r, codec, &list); err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, e := range list {
+		if e.ExpireAt.IsZero() || now.Before(e.ExpireAt) {
+			c.SetUntil(e.Key, e.Value, e.ExpireAt)
+			cnt++
+		}
+	}
+	return
+}
+
+// SaveFile 快照原子写入文件
+func (c *Cache[K, V]) SaveFile(path string, codec Codec) error {
+	return fs.WriteFileFunc(path, 0644, func(w io.Writer) error {
+		return c.Snapshot(w, codec)
+	})
+}
+
+// LoadFile 从文件恢复，文件不存在时返回 0
+func (c *Cache[K, V]) LoadFile(path string, codec Codec) (cnt int, err error) {
+	err = loadFile(path, func(r io.Reader) error {
+		cnt, err = c.Restore(r, codec)
+		return err
+	})
+	return
+}
+
+// AutoSave 每 interval 调用一次 save（interval <= 0 时只在结束时保存），ctx 结束时再保存一次后返回
+// 通常以协程运行：go sets.AutoSave(ctx, time.Minute, func() error { return s.SaveFile(path, sets.JSON) }, nil)
+func AutoSave(ctx context.Context, interval time.Duration, save func() error, errHandler func(err error)) {
+
+	if errHandler == nil {
+		errHandler = func(error) {}
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := save(); err != nil {
+				errHandler(err)
+			}
+			return
+		case <-tick:
+			if err := save(); err != nil {
+				errHandler(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package sets
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type snapshotValue struct {
+	Name string
+	N    int
+}
+
+func TestSetSnapshot(t *testing.T) {
+
+	for _, codec := range []Codec{JSON, Gob} {
+
+		s := &Set[int, *snapshotValue]{}
+		s.Set(1, &snapshotValue{"a", 1})
+		s.Set(2, &snapshotValue{"b", 2})
+
+		var buf bytes.Buffer
+		if err := s.Snapshot(&buf, codec); err != nil {
+			t.Fatal(err)
+		}
+
+		r := &Set[int, *snapshotValue]{}
+		if n, err := r.Restore(&buf, codec); err != nil || n != 2 {
+			t.Fatalf("codec %d restore %d %v", codec, n, err)
+		}
+		if v, ok := r.Get(2); !ok || v.Name != "b" || v.N != 2 {
+			t.Fatalf("codec %d get %+v", codec, v)
+		}
+	}
+}
+
+func TestCacheSnapshot(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	c := NewCache[string, int]()
+	c.SetTTL("short", 1, time.Millisecond*10)
+	c.SetTTL("long", 2, time.Hour)
+	c.Set("forever", 3)
+	if err := c.SaveFile(path, JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(time.Millisecond * 20)
+
+	r := NewCache[string, int]()
+	if n, err := r.LoadFile(path, JSON); err != nil || n != 2 {
+		t.Fatalf("load %d %v", n, err)
+	}
+	if _, ok := r.Get("short"); ok {
+		t.Fatal("expired entry restored")
+	}
+
+	// 不存在的文件
+	if n, err := r.LoadFile(path+".none", JSON); err != nil || n != 0 {
+		t.Fatalf("load none %d %v", n, err)
+	}
+}
+
+func TestAutoSave(t *testing.T) {
+
+	// interval <= 0 时只在结束时保存
+	for _, interval := range []time.Duration{time.Hour, 0} {
+
+		path := filepath.Join(t.TempDir(), "set.gob")
+		s := &Set[string, int]{}
+		s.Set("k", 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			AutoSave(ctx, interval, func() error {
+				return s.SaveFile(path, Gob)
+			}, func(err error) {
+				t.Error(err)
+			})
+		}()
+
+		cancel()
+		<-done
+
+		r := &Set[string, int]{}
+		if n, err := r.LoadFile(path, Gob); err != nil || n != 1 {
+			t.Fatalf("interval %s: load %d %v", interval, n, err)
+		}
+	}
+}
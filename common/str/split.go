@@ -0,0 +1,70 @@
+package str
+
+import (
+	"fmt"
+	"github.com/jack0829/letsgo/common/types"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// ParseError 第 Index 个值（从 0 开始）解析失败
+type ParseError struct {
+	Index int
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("第 %d 个值 %q 解析失败: %s", e.Index, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// SplitInteger JoinInteger 的逆操作，s 为空时返回空切片
+func SplitInteger[T types.Integer](s, sep string) ([]T, error) {
+	if s == "" {
+		return []T{}, nil
+	}
+	return ParseIntegers[T](strings.Split(s, sep))
+}
+
+// ParseIntegers 逐个解析整数，忽略首尾空白，超出 T 的范围视为错误
+func ParseIntegers[T types.Integer](ss []string) ([]T, error) {
+
+	var zero T
+	bits := int(unsafe.Sizeof(zero)) * 8
+	signed := ^zero < 0
+
+	r := make([]T, 0, len(ss))
+	for i, s := range ss {
+
+		s = strings.TrimSpace(s)
+
+		var (
+			v   T
+			err error
+		)
+		if signed {
+			var n int64
+			n, err = strconv.ParseInt(s, 10, bits)
+			v = T(n)
+		} else {
+			var n uint64
+			n, err = strconv.ParseUint(s, 10, bits)
+			v = T(n)
+		}
+
+		if err != nil {
+			if ne, ok := err.(*strconv.NumError); ok {
+				err = ne.Err
+			}
+			return nil, &ParseError{Index: i, Value: s, Err: err}
+		}
+		r = append(r, v)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,36 @@
+package str
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestSplitInteger(t *testing.T) {
+
+	in := []int64{-1, 0, 42}
+	out, err := SplitInteger[int64](JoinInteger(in, ","), ",")
+	if err != nil || fmt.Sprint(out) != fmt.Sprint(in) {
+		t.Fatalf("round trip %v %v", out, err)
+	}
+
+	if out, err := SplitInteger[int]("", ","); err != nil || len(out) != 0 {
+		t.Fatalf("empty %v %v", out, err)
+	}
+
+	if out, _ := SplitInteger[uint16](" 1, 2 ,3", ","); fmt.Sprint(out) != "[1 2 3]" {
+		t.Fatalf("spaces %v", out)
+	}
+
+	_, err = SplitInteger[int8]("1,2,200", ",")
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Index != 2 || !errors.Is(err, strconv.ErrRange) {
+		t.Fatalf("range %v", err)
+	}
+
+	_, err = ParseIntegers[uint]([]string{"1", "-1"})
+	if !errors.As(err, &pe) || pe.Index != 1 || !errors.Is(err, strconv.ErrSyntax) {
+		t.Fatalf("unsigned %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/jack0829/letsgo/common/limiter"
+	"net/http"
+)
+
+// RoundTripperFunc 函数形式的 http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (fn RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// AdaptiveRoundTripper 经过自适应并发限制的 RoundTripper
+// 耗时按收到响应头计算；网络错误、429 与 5xx 视为过载，调用方取消不参与调整
+func AdaptiveRoundTripper(next http.RoundTripper, l *limiter.Adaptive) http.RoundTripper {
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+		release, err := l.Acquire(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		release(outcome(req, resp, err))
+		return resp, err
+	})
+}
+
+func outcome(req *http.Request, resp *http.Response, err error) limiter.Outcome {
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || req.Context().Err() == context.Canceled {
+			return limiter.Ignore
+		}
+		return limiter.Dropped
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return limiter.Dropped
+	}
+
+	return limiter.Success
+}
@@ -0,0 +1,303 @@
+package breaker
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrOpen = fmt.Errorf("breaker: 熔断中")
+
+// State 熔断器状态
+type State uint8
+
+const (
+	Closed   State = iota // 正常放行
+	Open                  // 熔断，直接拒绝
+	HalfOpen              // 冷却结束，放行少量请求试探
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Result 一次请求的结果
+type Result uint8
+
+const (
+	Success Result = iota
+	Failure
+	Ignore // 不计入统计，例如调用方主动取消
+)
+
+// Event 状态变化
+type Event struct {
+	Host string
+	From State
+	To   State
+	At   time.Time
+}
+
+// Breaker 按 host 区分状态的熔断器
+// 连续失败达到阈值，或窗口内请求数足够且失败率达到阈值时熔断；冷却后半开试探，试探全部成功即恢复
+type Breaker struct {
+	mutex     sync.Mutex
+	circuits  map[string]*circuit
+	listeners []func(e Event)
+	cfg       config
+}
+
+type config struct {
+	consecutive int
+	rate        float64
+	minRequests int
+	window      time.Duration
+	cooldown    time.Duration
+	probes      int
+	failure     func(resp *http.Response, err error) bool
+	key         func(req *http.Request) string
+	metrics     *metrics
+}
+
+func New(ops ...Option) *Breaker {
+
+	b := &Breaker{
+		circuits: make(map[string]*circuit),
+		cfg: config{
+			consecutive: 5,
+			rate:        0.5,
+			minRequests: 20,
+			window:      time.Second * 10,
+			cooldown:    time.Second * 30,
+			probes:      1,
+			failure:     defaultFailure,
+			key: func(req *http.Request) string {
+				return req.URL.Host
+			},
+		},
+	}
+
+	for _, op := range ops {
+		op(b)
+	}
+
+	return b
+}
+
+// 网络错误、429 与 5xx 视为失败
+func defaultFailure(resp *http.Response, err error) bool {
+	return err != nil ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+// OnStateChange 订阅状态变化，回调在锁外同步执行
+func (b *Breaker) OnStateChange(fn func(e Event)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
+// State host 当前状态
+func (b *Breaker) State(host string) State {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c, ok := b.circuits[host]
+	if !ok {
+		return Closed
+	}
+	if c.state == Open && time.Since(c.openedAt) >= b.cfg.cooldown {
+		return HalfOpen
+	}
+	return c.state
+}
+
+// Allow 请求前调用，熔断时返回 ErrOpen；请求结束后调用 done 报告结果
+func (b *Breaker) Allow(host string) (done func(r Result), err error) {
+
+	b.mutex.Lock()
+
+	c, ok := b.circuits[host]
+	if !ok {
+		c = newCircuit(b.cfg.window)
+		b.circuits[host] = c
+	}
+
+	now := time.Now()
+	var events []Event
+
+	if c.state == Open {
+		if now.Sub(c.openedAt) < b.cfg.cooldown {
+			b.mutex.Unlock()
+			b.cfg.metrics.reject(host)
+			return nil, ErrOpen
+		}
+		events = append(events, b.transit(host, c, HalfOpen, now))
+	}
+
+	if c.state == HalfOpen {
+		if c.probing >= b.cfg.probes {
+			b.mutex.Unlock()
+			b.notify(events)
+			b.cfg.metrics.reject(host)
+			return nil, ErrOpen
+		}
+		c.probing++
+	}
+
+	gen := c.gen
+	b.mutex.Unlock()
+	b.notify(events)
+
+	var once sync.Once
+	return func(r Result) {
+		once.Do(func() {
+			b.done(host, c, gen, r)
+		})
+	}, nil
+}
+
+func (b *Breaker) done(host string, c *circuit, gen uint64, r Result) {
+
+	b.mutex.Lock()
+
+	// 状态已变化，结果作废
+	if gen != c.gen {
+		b.mutex.Unlock()
+		return
+	}
+
+	now := time.Now()
+	var events []Event
+
+	switch c.state {
+
+	case Closed:
+		if r == Ignore {
+			break
+		}
+		c.record(now, r == Failure)
+		if b.trip(c, now) {
+			events = append(events, b.transit(host, c, Open, now))
+		}
+
+	case HalfOpen:
+		c.probing--
+		switch r {
+		case Failure:
+			events = append(events, b.transit(host, c, Open, now))
+		case Success:
+			if c.successes++; c.successes >= b.cfg.probes {
+				events = append(events, b.transit(host, c, Closed, now))
+			}
+		}
+	}
+
+	b.mutex.Unlock()
+	b.notify(events)
+}
+
+// trip 是否应熔断，需持有锁
+func (b *Breaker) trip(c *circuit, now time.Time) bool {
+
+	if b.cfg.consecutive > 0 && c.consecutive >= b.cfg.consecutive {
+		return true
+	}
+
+	if b.cfg.rate > 0 {
+		total, failures := c.counts(now)
+		if total >= b.cfg.minRequests && float64(failures)/float64(total) >= b.cfg.rate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transit 切换状态，需持有锁
+func (b *Breaker) transit(host string, c *circuit, to State, now time.Time) Event {
+
+	e := Event{
+		Host: host,
+		From: c.state,
+		To:   to,
+		At:   now,
+	}
+
+	c.state = to
+	c.gen++
+	c.probing, c.successes = 0, 0
+
+	switch to {
+	case Open:
+		c.openedAt = now
+	case Closed:
+		c.reset()
+	}
+
+	b.cfg.metrics.transit(e)
+	return e
+}
+
+func (b *Breaker) notify(events []Event) {
+
+	if len(events) < 1 {
+		return
+	}
+
+	b.mutex.Lock()
+	listeners := append([]func(e Event){}, b.listeners...)
+	b.mutex.Unlock()
+
+	for _, e := range events {
+		for _, fn := range listeners {
+			fn(e)
+		}
+	}
+}
+
+// RoundTripper 经过熔断的 RoundTripper，next 为空时使用 http.DefaultTransport
+func (b *Breaker) RoundTripper(next http.RoundTripper) http.RoundTripper {
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+
+		done, err := b.Allow(b.cfg.key(req))
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		switch {
+		case req.Context().Err() != nil:
+			// 调用方主动取消不计入统计
+			done(Ignore)
+		case b.cfg.failure(resp, err):
+			done(Failure)
+		default:
+			done(Success)
+		}
+		return resp, err
+	})
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,161 @@
+package breaker
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func report(t *testing.T, b *Breaker, host string, r Result) {
+	t.Helper()
+	done, err := b.Allow(host)
+	if err != nil {
+		t.Fatalf("allow %s: %v", host, err)
+	}
+	done(r)
+}
+
+func TestConsecutive(t *testing.T) {
+
+	b := New(WithConsecutiveFailures(3), WithErrorRate(0, 0, 0), WithCooldown(time.Millisecond*30))
+
+	var events []Event
+	b.OnStateChange(func(e Event) {
+		events = append(events, e)
+	})
+
+	report(t, b, "a", Failure)
+	report(t, b, "a", Failure)
+	report(t, b, "a", Success) // 中断连续失败
+	for i := 0; i < 3; i++ {
+		report(t, b, "a", Failure)
+	}
+
+	if b.State("a") != Open || b.State("b") != Closed {
+		t.Fatalf("state a %s b %s", b.State("a"), b.State("b"))
+	}
+	if _, err := b.Allow("a"); err != ErrOpen {
+		t.Fatalf("expect ErrOpen, got %v", err)
+	}
+	report(t, b, "b", Success)
+
+	time.Sleep(time.Millisecond * 40)
+
+	// 半开只放行一个试探
+	done, err := b.Allow("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.Allow("a"); err != ErrOpen {
+		t.Fatalf("second probe: %v", err)
+	}
+	done(Success)
+
+	if b.State("a") != Closed {
+		t.Fatalf("state %s", b.State("a"))
+	}
+
+	want := []State{Open, HalfOpen, Closed}
+	if len(events) != len(want) {
+		t.Fatalf("events %+v", events)
+	}
+	for i, e := range events {
+		if e.To != want[i] || e.Host != "a" {
+			t.Fatalf("event %d %+v", i, e)
+		}
+	}
+}
+
+func TestErrorRate(t *testing.T) {
+
+	b := New(WithConsecutiveFailures(0), WithErrorRate(0.5, 10, time.Second), WithCooldown(time.Millisecond*20), WithProbes(2))
+
+	for i := 0; i < 9; i++ {
+		r := Success
+		if i%2 == 0 {
+			r = Failure
+		}
+		report(t, b, "h", r)
+	}
+	if b.State("h") != Closed {
+		t.Fatal("opened before min requests")
+	}
+
+	report(t, b, "h", Failure)
+	if b.State("h") != Open {
+		t.Fatalf("state %s", b.State("h"))
+	}
+
+	time.Sleep(time.Millisecond * 30)
+
+	// 试探失败重新熔断；旧试探的结果作废
+	d1, _ := b.Allow("h")
+	d2, _ := b.Allow("h")
+	d1(Failure)
+	d2(Success)
+	if b.State("h") != Open {
+		t.Fatalf("state %s", b.State("h"))
+	}
+
+	time.Sleep(time.Millisecond * 30)
+	report(t, b, "h", Ignore)
+	report(t, b, "h", Success)
+	if b.State("h") != HalfOpen {
+		t.Fatalf("state %s", b.State("h"))
+	}
+	report(t, b, "h", Success)
+	if b.State("h") != Closed {
+		t.Fatalf("state %s", b.State("h"))
+	}
+}
+
+func TestRoundTripper(t *testing.T) {
+
+	reg := prometheus.NewRegistry()
+	b := New(WithConsecutiveFailures(2), WithRegisterer(reg, nil))
+
+	var calls int
+	rt := b.RoundTripper(roundTripper(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if req.URL.Host == "down" {
+			return nil, errors.New("refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://down/x", nil)
+		rt.RoundTrip(req)
+		req, _ = http.NewRequest(http.MethodGet, "http://up/x", nil)
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if calls != 7 {
+		t.Fatalf("calls %d", calls)
+	}
+
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			switch {
+			case m.Gauge != nil:
+				values[mf.GetName()] = m.Gauge.GetValue()
+			case m.Counter != nil:
+				values[mf.GetName()] += m.Counter.GetValue()
+			}
+		}
+	}
+	if values["http_client_breaker_state"] != float64(Open) ||
+		values["http_client_breaker_transitions_total"] != 1 ||
+		values["http_client_breaker_rejected_total"] != 3 {
+		t.Fatalf("metrics %v", values)
+	}
+}
@@ -0,0 +1,70 @@
+package breaker
+
+import (
+	"time"
+)
+
+const buckets = 10
+
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// circuit 单个 host 的状态，由 Breaker 的锁保护
+type circuit struct {
+	state       State
+	gen         uint64 // 每次切换状态递增，丢弃旧状态下发出的请求结果
+	openedAt    time.Time
+	probing     int // 半开时进行中的试探
+	successes   int // 半开时成功的试探
+	consecutive int // 连续失败次数
+	span        time.Duration
+	window      [buckets]bucket // 滑动窗口，按时间分桶
+}
+
+func newCircuit(window time.Duration) *circuit {
+	span := window / buckets
+	if span <= 0 {
+		span = time.Millisecond
+	}
+	return &circuit{span: span}
+}
+
+func (c *circuit) record(now time.Time, failed bool) {
+
+	if failed {
+		c.consecutive++
+	} else {
+		c.consecutive = 0
+	}
+
+	start := now.Truncate(c.span)
+	b := &c.window[int(start.UnixNano()/int64(c.span))%buckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.total++
+	if failed {
+		b.failures++
+	}
+}
+
+// counts 窗口内的请求数与失败数
+func (c *circuit) counts(now time.Time) (total, failures int) {
+	oldest := now.Add(-c.span * buckets)
+	for _, b := range c.window {
+		if b.start.After(oldest) {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return
+}
+
+func (c *circuit) reset() {
+	c.consecutive = 0
+	c.window = [buckets]bucket{}
+}
@@ -0,0 +1,50 @@
+package breaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	state       *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+	rejected    *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer, constLabels prometheus.Labels) *metrics {
+
+	m := &metrics{
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "http_client_breaker_state",
+			Help:        "熔断器状态：0 closed，1 open，2 half-open",
+			ConstLabels: constLabels,
+		}, []string{"host"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_client_breaker_transitions_total",
+			Help:        "熔断器状态切换次数",
+			ConstLabels: constLabels,
+		}, []string{"host", "from", "to"}),
+		rejected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_client_breaker_rejected_total",
+			Help:        "熔断拒绝的请求数",
+			ConstLabels: constLabels,
+		}, []string{"host"}),
+	}
+
+	reg.MustRegister(m.state, m.transitions, m.rejected)
+	return m
+}
+
+func (m *metrics) transit(e Event) {
+	if m == nil {
+		return
+	}
+	m.state.WithLabelValues(e.Host).Set(float64(e.To))
+	m.transitions.WithLabelValues(e.Host, e.From.String(), e.To.String()).Inc()
+}
+
+func (m *metrics) reject(host string) {
+	if m == nil {
+		return
+	}
+	m.rejected.WithLabelValues(host).Inc()
+}
@@ -0,0 +1,71 @@
+package breaker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"time"
+)
+
+type Option func(b *Breaker)
+
+// WithConsecutiveFailures 连续失败 n 次即熔断，默认 5，0 表示不按连续失败熔断
+func WithConsecutiveFailures(n int) Option {
+	return func(b *Breaker) {
+		b.cfg.consecutive = n
+	}
+}
+
+// WithErrorRate window 内请求数不少于 minRequests 且失败率不低于 rate 时熔断
+// 默认 10 秒内至少 20 个请求、失败率 50%，rate 为 0 表示不按失败率熔断
+func WithErrorRate(rate float64, minRequests int, window time.Duration) Option {
+	return func(b *Breaker) {
+		b.cfg.rate = rate
+		b.cfg.minRequests = max(minRequests, 1)
+		if window > 0 {
+			b.cfg.window = window
+		}
+	}
+}
+
+// WithCooldown 熔断持续时间，之后进入半开，默认 30 秒
+func WithCooldown(d time.Duration) Option {
+	return func(b *Breaker) {
+		if d > 0 {
+			b.cfg.cooldown = d
+		}
+	}
+}
+
+// WithProbes 半开时放行的试探请求数，全部成功才恢复，默认 1
+func WithProbes(n int) Option {
+	return func(b *Breaker) {
+		if n > 0 {
+			b.cfg.probes = n
+		}
+	}
+}
+
+// WithFailure 自定义失败判断，默认网络错误、429 与 5xx
+func WithFailure(fn func(resp *http.Response, err error) bool) Option {
+	return func(b *Breaker) {
+		if fn != nil {
+			b.cfg.failure = fn
+		}
+	}
+}
+
+// WithKey 自定义状态的区分方式，默认按 req.URL.Host
+func WithKey(fn func(req *http.Request) string) Option {
+	return func(b *Breaker) {
+		if fn != nil {
+			b.cfg.key = fn
+		}
+	}
+}
+
+// WithRegisterer 注册 Prometheus 指标
+func WithRegisterer(reg prometheus.Registerer, constLabels prometheus.Labels) Option {
+	return func(b *Breaker) {
+		b.cfg.metrics = newMetrics(reg, constLabels)
+	}
+}
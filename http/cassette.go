@@ -0,0 +1,400 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/common/fs"
+	jsoniter "github.com/json-iterator/go"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrCassetteMiss 回放时没有匹配的录制
+var ErrCassetteMiss = errors.New("cassette: 没有匹配的录制")
+
+// CassetteMatch 回放时比较的内容，Host 不参与比较
+type CassetteMatch uint8
+
+const (
+	MatchMethod CassetteMatch = 1 << iota
+	MatchPath
+	MatchQuery // 参数顺序无关
+	MatchBody  // 均为 JSON 时按语义比较
+	MatchAll   = MatchMethod | MatchPath | MatchQuery | MatchBody
+)
+
+// Interaction 一次录制的请求与响应，敏感内容已隐藏
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   recordBody  `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   recordBody  `json:"body,omitempty"`
+}
+
+// recordBody 文本原样保存，二进制以 base64 保存
+type recordBody []byte
+
+func (b recordBody) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return jsoniter.Marshal(string(b))
+	}
+	return jsoniter.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *recordBody) UnmarshalJSON(data []byte) error {
+
+	var s string
+	if jsoniter.Unmarshal(data, &s) == nil {
+		*b = recordBody(s)
+		return nil
+	}
+
+	var m struct {
+		Base64 string `json:"base64"`
+	}
+	if err := jsoniter.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	v, err := base64.StdEncoding.DecodeString(m.Base64)
+	*b = v
+	return err
+}
+
+// Cassette 录制与回放出站请求，用于测试
+// 回放时按 CassetteMatch 依次匹配未使用过的录制，没有匹配时返回 ErrCassetteMiss
+// 录制时经 next 发出真实请求，隐藏敏感内容后写入文件
+type Cassette struct {
+	mutex        sync.Mutex
+	path         string
+	record       bool
+	match        CassetteMatch
+	headers      map[string]struct{}
+	query        map[string]struct{}
+	jsonKeys     map[string]struct{}
+	interactions []*Interaction
+	used         []bool
+}
+
+type CassetteOption func(c *Cassette)
+
+// CassetteRecord 录制模式，清空已有录制
+func CassetteRecord(record bool) CassetteOption {
+	return func(c *Cassette) {
+		c.record = record
+	}
+}
+
+// CassetteMatchOn 回放时比较的内容，默认 MatchAll
+func CassetteMatchOn(m CassetteMatch) CassetteOption {
+	return func(c *Cassette) {
+		c.match = m
+	}
+}
+
+// CassetteScrub 追加需隐藏值的请求头与响应头，默认同 Log
+func CassetteScrub(headers ...string) CassetteOption {
+	return func(c *Cassette) {
+		for _, h := range headers {
+			c.headers[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+	}
+}
+
+// CassetteScrubQuery 追加需隐藏值的 Query 参数，默认同 Log
+func CassetteScrubQuery(names ...string) CassetteOption {
+	return func(c *Cassette) {
+		for _, n := range names {
+			c.query[n] = struct{}{}
+		}
+	}
+}
+
+// CassetteScrubJSON 追加 JSON Body 中需隐藏值的字段（任意层级），默认 access_token、refresh_token、client_secret、secret、password
+func CassetteScrubJSON(keys ...string) CassetteOption {
+	return func(c *Cassette) {
+		for _, k := range keys {
+			c.jsonKeys[strings.ToLower(k)] = struct{}{}
+		}
+	}
+}
+
+// NewCassette 读取录制文件；录制模式下不读取
+func NewCassette(path string, ops ...CassetteOption) (*Cassette, error) {
+
+	c := &Cassette{
+		path:     path,
+		match:    MatchAll,
+		headers:  make(map[string]struct{}),
+		query:    make(map[string]struct{}),
+		jsonKeys: make(map[string]struct{}),
+	}
+	CassetteScrub(sensitiveHeaders...)(c)
+	CassetteScrubQuery(sensitiveQuery...)(c)
+	CassetteScrubJSON("access_token", "refresh_token", "client_secret", "secret", "password")(c)
+
+	for _, op := range ops {
+		op(c)
+	}
+
+	if c.record {
+		return c, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = jsoniter.Unmarshal(b, &c.interactions); err != nil {
+		return nil, fmt.Errorf("cassette: %s: %w", path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+
+	return c, nil
+}
+
+// Interactions 已录制或已加载的条目
+func (c *Cassette) Interactions() []*Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]*Interaction{}, c.interactions...)
+}
+
+// Unused 回放模式下未被使用的条目数，可用于断言请求都已发出
+func (c *Cassette) Unused() (n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, u := range c.used {
+		if !u {
+			n++
+		}
+	}
+	return
+}
+
+// RoundTripper 录制模式经 next 发出请求（为空时使用 http.DefaultTransport），回放模式不发出请求
+func (c *Cassette) RoundTripper(next http.RoundTripper) http.RoundTripper {
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+		req = req.Clone(req.Context())
+		body, err := readRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+		recorded := c.scrubRequest(req, body)
+
+		if !c.record {
+			return c.replay(req, recorded)
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+		return resp, c.save(&Interaction{
+			Request: recorded,
+			Response: RecordedResponse{
+				Status: resp.StatusCode,
+				Header: c.scrubHeader(resp.Header),
+				Body:   c.scrubJSON(respBody),
+			},
+		})
+	})
+}
+
+func (c *Cassette) replay(req *http.Request, r RecordedRequest) (*http.Response, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, it := range c.interactions {
+		if c.used[i] || !c.matches(&it.Request, &r) {
+			continue
+		}
+		c.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", it.Response.Status, http.StatusText(it.Response.Status)),
+			StatusCode:    it.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        it.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(it.Response.Body)),
+			ContentLength: int64(len(it.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, r.Method, r.URL)
+}
+
+func (c *Cassette) save(it *Interaction) error {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.interactions = append(c.interactions, it)
+	b, err := jsoniter.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(c.path, b, 0644)
+}
+
+func (c *Cassette) matches(a, b *RecordedRequest) bool {
+
+	if c.match&MatchMethod != 0 && a.Method != b.Method {
+		return false
+	}
+
+	ua, err := url.Parse(a.URL)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b.URL)
+	if err != nil {
+		return false
+	}
+	if c.match&MatchPath != 0 && ua.Path != ub.Path {
+		return false
+	}
+	if c.match&MatchQuery != 0 && !reflect.DeepEqual(ua.Query(), ub.Query()) {
+		return false
+	}
+
+	return c.match&MatchBody == 0 || bodyEqual(a.Body, b.Body)
+}
+
+func bodyEqual(a, b []byte) bool {
+
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var va, vb any
+	if jsoniter.Unmarshal(a, &va) != nil || jsoniter.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// readRequestBody 读取后替换 req.Body，无 Body 时返回 nil
+func readRequestBody(req *http.Request) ([]byte, error) {
+
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+func (c *Cassette) scrubRequest(req *http.Request, body []byte) RecordedRequest {
+
+	u := *req.URL
+	if qs := u.Query(); len(qs) > 0 {
+		for k := range qs {
+			if _, ok := c.query[k]; ok {
+				qs.Set(k, redacted)
+			}
+		}
+		u.RawQuery = qs.Encode()
+	}
+
+	return RecordedRequest{
+		Method: req.Method,
+		URL:    u.String(),
+		Header: c.scrubHeader(req.Header),
+		Body:   c.scrubJSON(body),
+	}
+}
+
+func (c *Cassette) scrubHeader(h http.Header) http.Header {
+
+	h = h.Clone()
+	for k := range h {
+		if _, ok := c.headers[http.CanonicalHeaderKey(k)]; ok {
+			h[k] = []string{redacted}
+		}
+	}
+	return h
+}
+
+// scrubJSON 非 JSON 原样返回
+func (c *Cassette) scrubJSON(b []byte) []byte {
+
+	var v any
+	if len(b) < 1 || jsoniter.Unmarshal(b, &v) != nil {
+		return b
+	}
+
+	if !c.scrubValue(v) {
+		return b
+	}
+
+	// 按 key 排序，录制结果稳定
+	s, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return s
+}
+
+// scrubValue 是否有字段被隐藏
+func (c *Cassette) scrubValue(v any) (changed bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			if _, ok := c.jsonKeys[strings.ToLower(k)]; ok {
+				v[k] = redacted
+				changed = true
+			} else if c.scrubValue(sub) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, sub := range v {
+			if c.scrubValue(sub) {
+				changed = true
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCassette(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "sid=secret-cookie")
+		b, _ := io.ReadAll(r.Body)
+		io.WriteString(w, `{"access_token":"secret-token","echo":`+string(b)+`}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	send := func(rt http.RoundTripper, method, uri, body string) (string, error) {
+		req, _ := http.NewRequest(method, srv.URL+uri, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret-auth")
+		resp, err := (&http.Client{Transport: rt}).Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b), nil
+	}
+
+	// 录制
+	rec, err := NewCassette(path, CassetteRecord(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := send(rec.RoundTripper(nil), http.MethodPost, "/a?b=2&a=1&client_secret=secret-qs", `{"x":1,"y":2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "secret-token") {
+		t.Fatalf("录制时应返回真实响应，got %s", got)
+	}
+
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "secret-") {
+		t.Fatalf("录制文件包含敏感内容：%s", b)
+	}
+
+	// 回放：Query 顺序、JSON 字段顺序不同也能匹配，且不发出请求
+	srv.Close()
+	play, err := NewCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt := play.RoundTripper(nil)
+	got, err = send(rt, http.MethodPost, "/a?a=1&b=2&client_secret=other", `{"y":2,"x":1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `"echo":{"x":1,"y":2}`) || play.Unused() != 0 {
+		t.Fatalf("got %s, unused %d", got, play.Unused())
+	}
+
+	// 已使用过或不匹配的请求
+	for _, c := range []struct{ method, uri, body string }{
+		{http.MethodPost, "/a?a=1&b=2&client_secret=other", `{"x":1,"y":2}`},
+		{http.MethodPost, "/a?a=1&b=3", `{"x":1,"y":2}`},
+		{http.MethodGet, "/a?a=1&b=2", ""},
+	} {
+		if _, err = send(rt, c.method, c.uri, c.body); !errors.Is(err, ErrCassetteMiss) {
+			t.Errorf("%s %s: err = %v", c.method, c.uri, err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package http
+
+import (
+	"bytes"
+	"github.com/jack0829/letsgo/log"
+	ZAP "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// 默认隐藏值的请求头与 Query 参数
+var (
+	sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "Signature"}
+	sensitiveQuery   = []string{"access_token", "client_secret", "secret", "password"}
+)
+
+type logConfig struct {
+	logger  *ZAP.Logger
+	level   zapcore.Level
+	headers bool
+	body    int
+	sample  float64
+	redact  map[string]struct{} // 规范化后的请求头名
+	query   map[string]struct{}
+}
+
+type LogOption func(c *logConfig)
+
+// LogWith 使用指定的 logger，默认 log.Z()，未初始化时输出到标准输出
+func LogWith(logger *ZAP.Logger) LogOption {
+	return func(c *logConfig) {
+		c.logger = logger
+	}
+}
+
+// LogLevel 成功请求的日志级别，默认 Debug；失败与 5xx 至少为 Warn
+func LogLevel(l zapcore.Level) LogOption {
+	return func(c *logConfig) {
+		c.level = l
+	}
+}
+
+// LogHeaders 记录请求头与响应头
+func LogHeaders(c *logConfig) {
+	c.headers = true
+}
+
+// LogBody 记录请求与响应 Body 的前 max 字节，默认 1024，0 表示不记录
+// 只在该请求会被记录时读取；text/event-stream 响应不读取，以免阻塞流式响应
+func LogBody(max int) LogOption {
+	return func(c *logConfig) {
+		c.body = max
+	}
+}
+
+// LogSample 成功请求的采样率（0 ~ 1），默认 1；失败与 5xx 总是记录
+func LogSample(rate float64) LogOption {
+	return func(c *logConfig) {
+		c.sample = rate
+	}
+}
+
+// LogRedact 追加需隐藏值的请求头，默认 Authorization、Proxy-Authorization、Cookie、Set-Cookie、Signature
+func LogRedact(headers ...string) LogOption {
+	return func(c *logConfig) {
+		for _, h := range headers {
+			c.redact[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+	}
+}
+
+// LogRedactQuery 追加需隐藏值的 Query 参数，默认 access_token、client_secret、secret、password
+func LogRedactQuery(names ...string) LogOption {
+	return func(c *logConfig) {
+		for _, n := range names {
+			c.query[n] = struct{}{}
+		}
+	}
+}
+
+var (
+	stdoutLogger     *ZAP.Logger
+	stdoutLoggerOnce sync.Once
+)
+
+func (c *logConfig) zap() *ZAP.Logger {
+
+	if c.logger != nil {
+		return c.logger
+	}
+	if l := log.Z(); l != nil {
+		return l
+	}
+
+	stdoutLoggerOnce.Do(func() {
+		stdoutLogger = ZAP.New(zapcore.NewCore(
+			zapcore.NewConsoleEncoder(ZAP.NewDevelopmentEncoderConfig()),
+			zapcore.Lock(os.Stdout),
+			zapcore.DebugLevel,
+		))
+	})
+	return stdoutLogger
+}
+
+// Log 结构化记录出站请求：方法、URL、状态码、耗时、大小，可选请求头与截断后的 Body
+// 敏感请求头与 Query 参数的值会被隐藏
+func Log(ops ...LogOption) Middleware {
+
+	c := &logConfig{
+		level:  zapcore.DebugLevel,
+		body:   1024,
+		sample: 1,
+		redact: make(map[string]struct{}),
+		query:  make(map[string]struct{}),
+	}
+	LogRedact(sensitiveHeaders...)(c)
+	LogRedactQuery(sensitiveQuery...)(c)
+
+	for _, op := range ops {
+		op(c)
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+			logger := c.zap()
+			sampled := c.sample >= 1 || rand.Float64() < c.sample
+			if !sampled && !logger.Core().Enabled(zapcore.WarnLevel) {
+				return next.RoundTrip(req)
+			}
+
+			fields := []zapcore.Field{
+				ZAP.String("method", req.Method),
+				ZAP.String("url", c.url(req.URL)),
+				ZAP.Int64("req_size", req.ContentLength),
+			}
+			if c.headers {
+				fields = append(fields, ZAP.Any("req_headers", c.header(req.Header)))
+			}
+			// 未采样或级别未启用时不会记录成功请求，无需读取 Body
+			peek := c.body > 0 && sampled && logger.Core().Enabled(c.level)
+			if peek && req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					b, _ := io.ReadAll(io.LimitReader(body, int64(c.body)))
+					body.Close()
+					fields = append(fields, ZAP.ByteString("req_body", b))
+				}
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			fields = append(fields, ZAP.Duration("duration", time.Since(start)))
+
+			level := c.level
+			if err != nil {
+				level = max(level, zapcore.WarnLevel)
+				fields = append(fields, ZAP.Error(err))
+			} else {
+				if resp.StatusCode >= http.StatusInternalServerError {
+					level = max(level, zapcore.WarnLevel)
+				}
+				fields = append(fields,
+					ZAP.Int("status", resp.StatusCode),
+					ZAP.Int64("resp_size", resp.ContentLength),
+				)
+				if c.headers {
+					fields = append(fields, ZAP.Any("resp_headers", c.header(resp.Header)))
+				}
+				if peek && resp.Body != nil && !streaming(resp) {
+					fields = append(fields, ZAP.ByteString("resp_body", peekBody(resp, c.body)))
+				}
+			}
+
+			if sampled || level >= zapcore.WarnLevel {
+				logger.Check(level, "http client").Write(fields...)
+			}
+			return resp, err
+		})
+	}
+}
+
+func (c *logConfig) header(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, v := range h {
+		if _, ok := c.redact[http.CanonicalHeaderKey(k)]; ok {
+			m[k] = redacted
+		} else {
+			m[k] = strings.Join(v, ", ")
+		}
+	}
+	return m
+}
+
+func (c *logConfig) url(u *url.URL) string {
+
+	qs := u.Query()
+	if len(qs) < 1 {
+		return u.String()
+	}
+
+	for k := range qs {
+		if _, ok := c.query[k]; ok {
+			qs.Set(k, redacted)
+		}
+	}
+
+	r := *u
+	r.RawQuery = qs.Encode()
+	return r.String()
+}
+
+// streaming 服务端推送的响应，读取前 max 字节会等到推送足够的数据
+func streaming(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// peekBody 读取前 max 字节用于记录，不影响调用方读取完整 Body
+func peekBody(resp *http.Response, max int) []byte {
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, int64(max)))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		io.MultiReader(bytes.NewReader(b), resp.Body),
+		resp.Body,
+	}
+	return b
+}
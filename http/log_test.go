@@ -0,0 +1,159 @@
+package http
+
+import (
+	"errors"
+	ZAP "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLog(t *testing.T) {
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        http.Header{"Set-Cookie": {"sid=1"}},
+			Body:          io.NopCloser(strings.NewReader("0123456789")),
+			ContentLength: 10,
+		}, nil
+	})
+
+	rt := Chain(base, Log(LogWith(ZAP.New(core)), LogHeaders, LogBody(4)))
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/a?client_secret=abc&x=1", strings.NewReader(`{"k":"v"}`))
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Trace", "t")
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 调用方仍能读到完整 Body
+	if b, _ := io.ReadAll(resp.Body); string(b) != "0123456789" {
+		t.Fatalf("body = %q", b)
+	}
+
+	if logs.Len() != 1 {
+		t.Fatalf("logs = %d", logs.Len())
+	}
+	m := logs.All()[0].ContextMap()
+
+	if u := m["url"].(string); strings.Contains(u, "abc") || !strings.Contains(u, "x=1") {
+		t.Errorf("url = %s", u)
+	}
+	if m["status"] != int64(http.StatusOK) || m["resp_size"] != int64(10) {
+		t.Errorf("status = %v, resp_size = %v", m["status"], m["resp_size"])
+	}
+	if m["req_body"] != `{"k"` || m["resp_body"] != "0123" {
+		t.Errorf("req_body = %v, resp_body = %v", m["req_body"], m["resp_body"])
+	}
+
+	reqHeaders := m["req_headers"].(map[string]string)
+	if reqHeaders["Authorization"] != redacted || reqHeaders["X-Trace"] != "t" {
+		t.Errorf("req_headers = %v", reqHeaders)
+	}
+	if h := m["resp_headers"].(map[string]string); h["Set-Cookie"] != redacted {
+		t.Errorf("resp_headers = %v", h)
+	}
+}
+
+func TestLogSample(t *testing.T) {
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	status := http.StatusOK
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if status == 0 {
+			return nil, errors.New("refused")
+		}
+		return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+	})
+
+	rt := Chain(base, Log(LogWith(ZAP.New(core)), LogSample(0)))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+
+	rt.RoundTrip(req)
+	if logs.Len() != 0 {
+		t.Fatalf("成功请求不应被采样，logs = %d", logs.Len())
+	}
+
+	// 失败与 5xx 总是以 Warn 记录
+	status = http.StatusBadGateway
+	rt.RoundTrip(req)
+	status = 0
+	rt.RoundTrip(req)
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("logs = %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Level != zapcore.WarnLevel {
+			t.Errorf("level = %s", e.Level)
+		}
+	}
+	if entries[1].ContextMap()["error"] != "refused" {
+		t.Errorf("error = %v", entries[1].ContextMap()["error"])
+	}
+}
+
+// readCounter 记录 Body 是否被读取
+type readCounter struct {
+	io.Reader
+	reads int
+}
+
+func (r *readCounter) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+// 不会记录时不读取 Body；推送流不读取 Body
+func TestLogNoPeek(t *testing.T) {
+
+	info, _ := observer.New(zapcore.InfoLevel)
+	debug, logs := observer.New(zapcore.DebugLevel)
+
+	var (
+		body  *readCounter
+		ctype string
+	)
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body = &readCounter{Reader: strings.NewReader("0123456789")}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {ctype}},
+			Body:       io.NopCloser(body),
+		}, nil
+	})
+
+	cases := map[string]Middleware{
+		"level disabled": Log(LogWith(ZAP.New(info))),
+		"not sampled":    Log(LogWith(ZAP.New(debug)), LogSample(0)),
+	}
+	for name, mw := range cases {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+		if _, err := Chain(base, mw).RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if body.reads != 0 {
+			t.Errorf("%s: body read %d times", name, body.reads)
+		}
+	}
+
+	ctype = "text/event-stream"
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	if _, err := Chain(base, Log(LogWith(ZAP.New(debug)))).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if body.reads != 0 || logs.Len() != 1 {
+		t.Errorf("event-stream: body read %d times, logs %d", body.reads, logs.Len())
+	}
+	if _, ok := logs.All()[0].ContextMap()["resp_body"]; ok {
+		t.Error("event-stream: unexpected resp_body")
+	}
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"crypto/tls"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 出站请求各阶段，见 http_client_phase_duration_seconds
+const (
+	PhaseDNS       = "dns"
+	PhaseConnect   = "connect"
+	PhaseTLS       = "tls"
+	PhaseFirstByte = "first_byte" // 请求发出到收到首字节
+)
+
+type clientMetrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inflight *prometheus.GaugeVec
+	phases   *prometheus.HistogramVec
+}
+
+func newClientMetrics(constLabels prometheus.Labels) *clientMetrics {
+	return &clientMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_client_requests_total",
+			Help:        "出站 HTTP 请求计数（已收到响应）",
+			ConstLabels: constLabels,
+		}, []string{"host", "method", "code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_client_errors_total",
+			Help:        "出站 HTTP 请求错误计数（未收到响应）",
+			ConstLabels: constLabels,
+		}, []string{"host", "method"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "http_client_request_duration_seconds",
+			Help:        "出站 HTTP 请求耗时，至收到响应头（秒）",
+			ConstLabels: constLabels,
+		}, []string{"host", "method"}),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "http_client_requests_in_flight",
+			Help:        "进行中的出站 HTTP 请求数",
+			ConstLabels: constLabels,
+		}, []string{"host"}),
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "http_client_phase_duration_seconds",
+			Help:        "出站 HTTP 请求各阶段耗时：dns、connect、tls、first_byte（秒）",
+			ConstLabels: constLabels,
+			Buckets:     []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"host", "phase"}),
+	}
+}
+
+func (c *clientMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.requests, c.errors, c.duration, c.inflight, c.phases}
+}
+
+// RoundTripper 记录出站请求的计数、耗时、并发与 DNS、建连、TLS 耗时，标签为 host 与 method
+// 可直接用于 FH.Transport.Use(m.RoundTripper)；next 为空时使用 http.DefaultTransport
+func (m *Metrics) RoundTripper(next http.RoundTripper) http.RoundTripper {
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	c := m.client
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+
+		host, method := req.URL.Host, req.Method
+		inflight := c.inflight.WithLabelValues(host)
+		inflight.Inc()
+		defer inflight.Dec()
+
+		start := time.Now()
+		exemplar := Exemplar(req.Context())
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), c.trace(host)))
+		resp, err := next.RoundTrip(req)
+		observeWithExemplar(c.duration.WithLabelValues(host, method), time.Since(start).Seconds(), exemplar)
+
+		if err != nil {
+			c.errors.WithLabelValues(host, method).Inc()
+			return nil, err
+		}
+
+		c.requests.WithLabelValues(host, method, strconv.Itoa(resp.StatusCode)).Inc()
+		return resp, nil
+	})
+}
+
+// trace 各回调可能在不同协程执行，开始时间加锁保存
+func (c *clientMetrics) trace(host string) *httptrace.ClientTrace {
+
+	var (
+		mutex                sync.Mutex
+		dns, tlsStart, wrote time.Time
+		connects             = make(map[string]time.Time)
+	)
+
+	observe := func(phase string, since time.Time) {
+		if !since.IsZero() {
+			c.phases.WithLabelValues(host, phase).Observe(time.Since(since).Seconds())
+		}
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mutex.Lock()
+			dns = time.Now()
+			mutex.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if info.Err == nil {
+				observe(PhaseDNS, dns)
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			mutex.Lock()
+			connects[network+addr] = time.Now()
+			mutex.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				observe(PhaseConnect, connects[network+addr])
+			}
+		},
+		TLSHandshakeStart: func() {
+			mutex.Lock()
+			tlsStart = time.Now()
+			mutex.Unlock()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				observe(PhaseTLS, tlsStart)
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mutex.Lock()
+			wrote = time.Now()
+			mutex.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			observe(PhaseFirstByte, wrote)
+		},
+	}
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
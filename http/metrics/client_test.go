@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientRoundTripper(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	m := New("test")
+	cl := &http.Client{Transport: m.RoundTripper(nil)}
+
+	for range 3 {
+		resp, err := cl.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// 连接被拒绝
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	if _, err := cl.Get(closed.URL); err == nil {
+		t.Fatal("应返回错误")
+	}
+
+	host := srv.Listener.Addr().String()
+	if n := counterValue(t, m.client.requests.WithLabelValues(host, http.MethodGet, "418")); n != 3 {
+		t.Errorf("requests = %v", n)
+	}
+	if n := counterValue(t, m.client.errors.WithLabelValues(closed.Listener.Addr().String(), http.MethodGet)); n != 1 {
+		t.Errorf("errors = %v", n)
+	}
+	if n := counterValue(t, m.client.inflight.WithLabelValues(host)); n != 0 {
+		t.Errorf("inflight = %v", n)
+	}
+
+	// 连接复用，只建连一次；每次请求都有首字节耗时
+	var metric dto.Metric
+	m.client.phases.WithLabelValues(host, PhaseConnect).(prometheus.Histogram).Write(&metric)
+	if n := metric.GetHistogram().GetSampleCount(); n != 1 {
+		t.Errorf("connect = %d", n)
+	}
+	m.client.phases.WithLabelValues(host, PhaseFirstByte).(prometheus.Histogram).Write(&metric)
+	if n := metric.GetHistogram().GetSampleCount(); n != 3 {
+		t.Errorf("first_byte = %d", n)
+	}
+}
+
+func counterValue(t *testing.T, c prometheus.Metric) float64 {
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Counter != nil {
+		return m.Counter.GetValue()
+	}
+	return m.Gauge.GetValue()
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Counter 注册业务计数器，自动带上服务的固定标签；同名同类型重复注册返回已有的
+func (m *Metrics) Counter(name, help string, labels ...string) *prometheus.CounterVec {
+	return custom(m, name, func() *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: m.opts.ConstLabels,
+		}, labels)
+	})
+}
+
+// Gauge 注册业务仪表盘，见 Counter
+func (m *Metrics) Gauge(name, help string, labels ...string) *prometheus.GaugeVec {
+	return custom(m, name, func() *prometheus.GaugeVec {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: m.opts.ConstLabels,
+		}, labels)
+	})
+}
+
+// Histogram 注册业务直方图，buckets 为空时使用 prometheus.DefBuckets，见 Counter
+func (m *Metrics) Histogram(name, help string, buckets []float64, labels ...string) *prometheus.HistogramVec {
+	return custom(m, name, func() *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: m.opts.ConstLabels,
+			Buckets:     buckets,
+		}, labels)
+	})
+}
+
+// Summary 注册业务摘要，objectives 为分位数与允许误差，如 {0.5: 0.05, 0.99: 0.001}，见 Counter
+func (m *Metrics) Summary(name, help string, objectives map[float64]float64, labels ...string) *prometheus.SummaryVec {
+	return custom(m, name, func() *prometheus.SummaryVec {
+		return prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Name:        name,
+			Help:        help,
+			ConstLabels: m.opts.ConstLabels,
+			Objectives:  objectives,
+		}, labels)
+	})
+}
+
+// custom 名称冲突或参数不合法时 panic，与 MustRegister 一致
+func custom[C prometheus.Collector](m *Metrics, name string, create func() C) C {
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if exist, ok := m.custom[name]; ok {
+		if c, ok := exist.(C); ok {
+			return c
+		}
+		panic(fmt.Errorf("metrics: %s 已注册为其他类型", name))
+	}
+
+	c := create()
+	m.registry.MustRegister(c)
+	m.custom[name] = c
+	return c
+}
+
+// Register 注册自定义的 Collector，自动带上服务的固定标签
+func (m *Metrics) Register(c prometheus.Collector) error {
+	return m.Registerer().Register(c)
+}
+
+// Registerer 带服务固定标签的 Registerer，可用于其他包的指标，如 breaker.WithRegisterer(m.Registerer(), nil)
+func (m *Metrics) Registerer() prometheus.Registerer {
+	return prometheus.WrapRegistererWith(m.opts.ConstLabels, m.registry)
+}
+
+func (m *Metrics) registerRuntime() {
+	m.Registerer().MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
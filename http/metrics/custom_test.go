@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCustom(t *testing.T) {
+
+	m := New("test", WithRuntime())
+
+	orders := m.Counter("orders_total", "订单数", "channel")
+	orders.WithLabelValues("app").Add(2)
+	if m.Counter("orders_total", "订单数", "channel") != orders {
+		t.Fatal("重复注册应返回已有的")
+	}
+
+	m.Gauge("queue_length", "队列长度").WithLabelValues().Set(5)
+	m.Histogram("job_seconds", "任务耗时", []float64{1, 10}).WithLabelValues().Observe(3)
+	m.Summary("payload_bytes", "消息大小", map[float64]float64{0.5: 0.05}).WithLabelValues().Observe(100)
+
+	if err := m.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "缓存命中",
+	}, func() float64 { return 7 })); err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("同名不同类型应 panic")
+			}
+		}()
+		m.Gauge("orders_total", "订单数")
+	}()
+
+	w := httptest.NewRecorder()
+	m.Exporter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	out := w.Body.String()
+
+	for _, want := range []string{
+		`orders_total{channel="app",svc="test"} 2`,
+		`queue_length{svc="test"} 5`,
+		`job_seconds_bucket{svc="test",le="10"} 1`,
+		`payload_bytes{svc="test",quantile="0.5"} 100`,
+		`cache_hits_total{svc="test"} 7`,
+		`go_goroutines{svc="test"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("缺少 %s", want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"github.com/jack0829/letsgo/http/trace"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exemplar ctx 中有采样的链路时返回 {trace_id, span_id}，用于关联指标与链路，否则返回 nil
+func Exemplar(ctx context.Context) prometheus.Labels {
+
+	sc, ok := trace.FromContext(ctx)
+	if !ok || !sc.IsValid() || !sc.Sampled() {
+		return nil
+	}
+
+	return prometheus.Labels{
+		"trace_id": sc.TraceID.String(),
+		"span_id":  sc.SpanID.String(),
+	}
+}
+
+func observeWithExemplar(o prometheus.Observer, v float64, exemplar prometheus.Labels) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(v, exemplar)
+		return
+	}
+	o.Observe(v)
+}
+
+func addWithExemplar(c prometheus.Counter, v float64, exemplar prometheus.Labels) {
+	if ea, ok := c.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		ea.AddWithExemplar(v, exemplar)
+		return
+	}
+	c.Add(v)
+}
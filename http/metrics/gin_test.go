@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGin(t *testing.T) {
+
+	m := New("test", WithExclude("/metrics", "/healthz"), WithBuckets(0.1, 1))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Gin)
+	r.GET("/metrics", gin.WrapH(m.Exporter()))
+	r.GET("/healthz", func(g *gin.Context) { g.Status(http.StatusOK) })
+	r.POST("/user/:id", func(g *gin.Context) {
+		if g.Param("id") == "0" {
+			g.Status(http.StatusBadRequest)
+			return
+		}
+		g.String(http.StatusOK, "hello")
+	})
+
+	serve := func(method, path, body string) string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w.Body.String()
+	}
+
+	serve(http.MethodPost, "/user/1", "{}")
+	serve(http.MethodPost, "/user/2", "{}")
+	serve(http.MethodPost, "/user/0", "")
+	serve(http.MethodGet, "/nothing", "")
+	serve(http.MethodGet, "/healthz", "")
+	m.IncRequestTotal(http.MethodGet, "/manual")
+	m.IncRequestTotalWithStatus(http.MethodGet, "/manual", StatusClass(http.StatusOK))
+	m.ObserveRequestDuration(http.MethodGet, "/manual", 0)
+	out := serve(http.MethodGet, "/metrics", "")
+
+	for _, want := range []string{
+		`http_request_total{method="POST",path="/user/:id",status="2xx",svc="test"} 2`,
+		`http_request_total{method="POST",path="/user/:id",status="4xx",svc="test"} 1`,
+		`http_request_total{method="GET",path="<unmatched>",status="4xx",svc="test"} 1`,
+		`http_request_duration_seconds_bucket{method="POST",path="/user/:id",status="2xx",svc="test",le="0.1"} 2`,
+		`http_request_size_bytes_sum{method="POST",path="/user/:id",svc="test"} 4`,
+		`http_response_size_bytes_sum{method="POST",path="/user/:id",svc="test"} 10`,
+		`http_requests_in_flight{svc="test"} 0`,
+		`http_request_total{method="GET",path="/manual",status="unknown",svc="test"} 1`,
+		`http_request_total{method="GET",path="/manual",status="2xx",svc="test"} 1`,
+		`http_request_duration_seconds_count{method="GET",path="/manual",status="unknown",svc="test"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("缺少 %s", want)
+		}
+	}
+
+	for _, bad := range []string{`path="/user/1"`, `path="/healthz"`, `path="/metrics"`} {
+		if strings.Contains(out, bad) {
+			t.Errorf("不应包含 %s", bad)
+		}
+	}
+}
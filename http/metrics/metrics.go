@@ -5,14 +5,30 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// PathUnmatched 没有匹配到路由的请求（如 404）统一使用的 path 标签，避免按原始路径产生大量时间序列
+const PathUnmatched = "<unmatched>"
+
 type Metrics struct {
 	opts                       prometheus.Opts
 	registry                   *prometheus.Registry
+	buckets                    []float64
+	sizeBuckets                []float64
+	exclude                    map[string]struct{}
+	runtime                    bool
+	mutex                      sync.Mutex
+	custom                     map[string]prometheus.Collector
 	httpRequestTotal           *prometheus.CounterVec
 	httpRequestDurationSeconds *prometheus.HistogramVec
+	httpRequestSizeBytes       *prometheus.HistogramVec
+	httpResponseSizeBytes      *prometheus.HistogramVec
+	httpRequestsInFlight       prometheus.Gauge
+	client                     *clientMetrics
+	slos                       *sloSet
 }
 
 func New(svc string, ops ...Option) *Metrics {
@@ -23,7 +39,11 @@ func New(svc string, ops ...Option) *Metrics {
 				"svc": svc,
 			},
 		},
-		registry: prometheus.NewRegistry(),
+		registry:    prometheus.NewRegistry(),
+		buckets:     prometheus.DefBuckets,
+		sizeBuckets: prometheus.ExponentialBuckets(100, 10, 7), // 100B ~ 100MB
+		exclude:     make(map[string]struct{}),
+		custom:      make(map[string]prometheus.Collector),
 	}
 	for _, op := range ops {
 		op(m)
@@ -33,45 +53,144 @@ func New(svc string, ops ...Option) *Metrics {
 		Name:        "http_request_total",
 		Help:        "HTTP 请求计数",
 		ConstLabels: m.opts.ConstLabels,
-	}, []string{"method", "path"})
+	}, []string{"method", "path", "status"})
 
 	m.httpRequestDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:        "http_request_duration_seconds",
 		Help:        "HTTP 请求响应时长（秒）",
 		ConstLabels: m.opts.ConstLabels,
+		Buckets:     m.buckets,
+	}, []string{"method", "path", "status"})
+
+	m.httpRequestSizeBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "http_request_size_bytes",
+		Help:        "HTTP 请求 Body 大小（字节）",
+		ConstLabels: m.opts.ConstLabels,
+		Buckets:     m.sizeBuckets,
+	}, []string{"method", "path"})
+
+	m.httpResponseSizeBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "http_response_size_bytes",
+		Help:        "HTTP 响应 Body 大小（字节）",
+		ConstLabels: m.opts.ConstLabels,
+		Buckets:     m.sizeBuckets,
 	}, []string{"method", "path"})
 
+	m.httpRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        "http_requests_in_flight",
+		Help:        "处理中的 HTTP 请求数",
+		ConstLabels: m.opts.ConstLabels,
+	})
+
 	m.registry.MustRegister(
 		m.httpRequestTotal,
 		m.httpRequestDurationSeconds,
+		m.httpRequestSizeBytes,
+		m.httpResponseSizeBytes,
+		m.httpRequestsInFlight,
 	)
+
+	m.client = newClientMetrics(m.opts.ConstLabels)
+	m.registry.MustRegister(m.client.collectors()...)
+
+	m.slos = newSLOSet(m.opts.ConstLabels)
+	m.registry.MustRegister(m.slos)
+
+	if m.runtime {
+		m.registerRuntime()
+	}
 	return m
 }
 
+// StatusUnknown 无法分类或未提供状态码时的 status 标签
+const StatusUnknown = "unknown"
+
+// StatusClass 状态码分类，如 200 -> 2xx
+func StatusClass(code int) string {
+	if code < 100 || code > 599 {
+		return StatusUnknown
+	}
+	return strconv.Itoa(code/100) + "xx"
+}
+
+// IncRequestTotal status 标签为 unknown，见 IncRequestTotalWithStatus
 func (m *Metrics) IncRequestTotal(method, path string) {
-	m.httpRequestTotal.WithLabelValues(method, path).Inc()
+	m.IncRequestTotalWithStatus(method, path, StatusUnknown)
+}
+
+// IncRequestTotalWithStatus status 为状态码分类，见 StatusClass
+func (m *Metrics) IncRequestTotalWithStatus(method, path, status string) {
+	m.httpRequestTotal.WithLabelValues(method, path, status).Inc()
 }
 
+// ObserveRequestDuration status 标签为 unknown，见 ObserveRequestDurationWithStatus
 func (m *Metrics) ObserveRequestDuration(method, path string, d time.Duration) {
-	m.httpRequestDurationSeconds.WithLabelValues(method, path).Observe(d.Seconds())
+	m.ObserveRequestDurationWithStatus(method, path, StatusUnknown, d)
 }
 
-// Gin 专用中间件
+// ObserveRequestDurationWithStatus status 为状态码分类，见 StatusClass
+func (m *Metrics) ObserveRequestDurationWithStatus(method, path, status string, d time.Duration) {
+	m.httpRequestDurationSeconds.WithLabelValues(method, path, status).Observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveRequestSize(method, path string, size int64) {
+	m.httpRequestSizeBytes.WithLabelValues(method, path).Observe(float64(size))
+}
+
+func (m *Metrics) ObserveResponseSize(method, path string, size int64) {
+	m.httpResponseSizeBytes.WithLabelValues(method, path).Observe(float64(size))
+}
+
+// Gin 专用中间件，path 标签使用路由模板（如 /user/:id），status 标签为状态码分类
 func (m *Metrics) Gin(g *gin.Context) {
+
+	path := g.FullPath()
+	if m.excluded(path, g.Request.URL.Path) {
+		g.Next()
+		return
+	}
+	if path == "" {
+		path = PathUnmatched
+	}
+
+	m.httpRequestsInFlight.Inc()
+	defer m.httpRequestsInFlight.Dec()
+
 	t := time.Now()
 	g.Next()
 	d := time.Since(t)
+
 	method := g.Request.Method
-	path := g.Request.URL.Path
-	m.IncRequestTotal(method, path)
-	m.ObserveRequestDuration(method, path, d)
+	status := StatusClass(g.Writer.Status())
+	exemplar := Exemplar(g.Request.Context())
+	addWithExemplar(m.httpRequestTotal.WithLabelValues(method, path, status), 1, exemplar)
+	observeWithExemplar(m.httpRequestDurationSeconds.WithLabelValues(method, path, status), d.Seconds(), exemplar)
+	if path != PathUnmatched {
+		m.slos.record(path, g.Writer.Status(), d)
+	}
+
+	if size := g.Request.ContentLength; size >= 0 {
+		m.ObserveRequestSize(method, path, size)
+	}
+	m.ObserveResponseSize(method, path, int64(max(g.Writer.Size(), 0)))
+}
+
+func (m *Metrics) excluded(paths ...string) bool {
+	for _, p := range paths {
+		if _, ok := m.exclude[p]; ok && p != "" {
+			return true
+		}
+	}
+	return false
 }
 
-// Exporter prometheus 指标收集接口
+// Exporter prometheus 指标收集接口，按 Accept 协商使用 OpenMetrics 格式（含 exemplar）
 // gin 示例 r.GET("/metrics", gin.WrapH(m.Exporter()))
 func (m *Metrics) Exporter() http.Handler {
 	return promhttp.InstrumentMetricHandler(
 		m.registry,
-		promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}),
+		promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+			EnableOpenMetrics: true,
+		}),
 	)
 }
@@ -16,3 +16,37 @@ func WithLabels(kv map[string]string) Option {
 		}
 	}
 }
+
+// WithBuckets 请求耗时直方图的分桶（秒），默认 prometheus.DefBuckets
+func WithBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		if len(buckets) > 0 {
+			m.buckets = buckets
+		}
+	}
+}
+
+// WithSizeBuckets 请求与响应大小直方图的分桶（字节），默认 100B 起按 10 倍递增至 100MB
+func WithSizeBuckets(buckets ...float64) Option {
+	return func(m *Metrics) {
+		if len(buckets) > 0 {
+			m.sizeBuckets = buckets
+		}
+	}
+}
+
+// WithExclude 不统计的路径，可以是路由模板或原始路径，如 /metrics、/healthz
+func WithExclude(paths ...string) Option {
+	return func(m *Metrics) {
+		for _, p := range paths {
+			m.exclude[p] = struct{}{}
+		}
+	}
+}
+
+// WithRuntime 同时导出 Go 运行时（go_*）与进程（process_*）指标
+func WithRuntime() Option {
+	return func(m *Metrics) {
+		m.runtime = true
+	}
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"time"
+)
+
+// Pusher 推送全部指标到 Pushgateway，job 为分组名；可继续设置 Grouping、BasicAuth、Client 等
+// 适用于抓取前就结束的批处理任务
+func (m *Metrics) Pusher(url, job string) *push.Pusher {
+	return push.New(url, job).Gatherer(m.registry)
+}
+
+// AutoPush 每 interval 推送一次（interval <= 0 时只在结束时推送），ctx 结束时再推送一次后返回
+// 推送使用 PUT，替换同一分组下的全部指标
+// 通常以协程运行：go metrics.AutoPush(ctx, m.Pusher(url, "ngram"), time.Minute, nil)
+func AutoPush(ctx context.Context, p *push.Pusher, interval time.Duration, errHandler func(err error)) {
+
+	if errHandler == nil {
+		errHandler = func(error) {}
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx 已结束，最后一次推送另设超时
+			final, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			defer cancel()
+			if err := p.PushContext(final); err != nil {
+				errHandler(err)
+			}
+			return
+		case <-tick:
+			if err := p.PushContext(ctx); err != nil && ctx.Err() == nil {
+				errHandler(err)
+			}
+		}
+	}
+}
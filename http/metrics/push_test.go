@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/jack0829/letsgo/http/trace"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAutoPush(t *testing.T) {
+
+	var (
+		mutex  sync.Mutex
+		pushes []string
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		pushes = append(pushes, r.Method+" "+r.URL.Path+" "+string(b))
+		mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	m := New("test")
+	m.Counter("rows_total", "处理行数").WithLabelValues().Add(42)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		AutoPush(ctx, m.Pusher(gateway.URL, "ngram"), time.Millisecond*20, func(err error) {
+			t.Error(err)
+		})
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	cancel()
+	<-done
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// 至少一次定时推送，加上结束时的一次
+	if len(pushes) < 2 {
+		t.Fatalf("pushes = %d", len(pushes))
+	}
+	last := pushes[len(pushes)-1]
+	if !strings.HasPrefix(last, http.MethodPut+" /metrics/job/ngram ") || !strings.Contains(last, "rows_total") {
+		t.Errorf("last push = %.80s", last)
+	}
+}
+
+func TestExemplar(t *testing.T) {
+
+	m := New("test")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Gin, trace.Gin)
+	r.GET("/metrics", gin.WrapH(m.Exporter()))
+	r.GET("/hello", func(g *gin.Context) { g.String(http.StatusOK, "hello") })
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.Header.Set(trace.HeaderTraceParent, parent)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	w := httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	r.ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("缺少 exemplar：\n%s", w.Body.String())
+	}
+}
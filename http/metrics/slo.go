@@ -0,0 +1,291 @@
+package metrics
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SLO 按路由定义的服务目标
+// Latency 为 0 时是可用性目标（非 5xx 即达标），否则还需耗时不超过 Latency，且 Latency 须是耗时直方图的分桶之一
+type SLO struct {
+	Name      string        // 标签 slo 的值，如 api-latency
+	Routes    []string      // 路由模板，以 * 结尾时按前缀匹配，如 /api/*
+	Objective float64       // 达标比例，如 0.99
+	Latency   time.Duration // 如 300ms
+	Period    time.Duration // 错误预算周期，整小时且不短于 6h，默认 30 天
+}
+
+// sloWindows 导出燃烧率的窗口，最长不超过 sloBuckets 分钟
+var sloWindows = []time.Duration{
+	time.Minute * 5,
+	time.Minute * 30,
+	time.Hour,
+	time.Hour * 6,
+}
+
+const (
+	sloBuckets = 6 * 60              // 按分钟分桶，覆盖 sloWindows
+	sloPeriod  = time.Hour * 24 * 30 // 默认错误预算周期，按小时分桶
+)
+
+func (s *SLO) validate(buckets []float64) error {
+
+	if s.Name == "" || len(s.Routes) < 1 {
+		return fmt.Errorf("metrics: SLO 需要 Name 与 Routes")
+	}
+	if s.Objective <= 0 || s.Objective >= 1 {
+		return fmt.Errorf("metrics: SLO %s 的 Objective 需在 (0, 1) 之间", s.Name)
+	}
+	if s.Period%time.Hour != 0 || s.Period < sloBuckets*time.Minute {
+		return fmt.Errorf("metrics: SLO %s 的 Period %s 需为整小时且不短于 6h", s.Name, s.Period)
+	}
+
+	if s.Latency > 0 {
+		for _, b := range buckets {
+			if b == s.Latency.Seconds() {
+				return nil
+			}
+		}
+		return fmt.Errorf("metrics: SLO %s 的 Latency %s 不在耗时分桶中，见 WithBuckets", s.Name, s.Latency)
+	}
+	return nil
+}
+
+func (s *SLO) match(path string) bool {
+	for _, r := range s.Routes {
+		if prefix, ok := strings.CutSuffix(r, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if r == path {
+			return true
+		}
+	}
+	return false
+}
+
+type sloBucket struct {
+	at         int64 // 第几分钟或第几小时
+	total, bad uint64
+}
+
+// sloRing 环形分桶，按 unit 滚动
+type sloRing struct {
+	unit    time.Duration
+	buckets []sloBucket
+}
+
+func (r *sloRing) add(now time.Time, bad bool) {
+
+	at := now.Unix() / int64(r.unit/time.Second)
+	b := &r.buckets[at%int64(len(r.buckets))]
+	if b.at != at {
+		*b = sloBucket{at: at}
+	}
+	b.total++
+	if bad {
+		b.bad++
+	}
+}
+
+// errorRate 窗口内未达标比例，没有请求时为 0
+func (r *sloRing) errorRate(now time.Time, window time.Duration) float64 {
+
+	at := now.Unix() / int64(r.unit/time.Second)
+	since := at - int64(window/r.unit)
+
+	var total, bad uint64
+	for _, b := range r.buckets {
+		if b.at > since && b.at <= at {
+			total += b.total
+			bad += b.bad
+		}
+	}
+
+	if total < 1 {
+		return 0
+	}
+	return float64(bad) / float64(total)
+}
+
+// sloTracker 最近 6 小时按分钟、Period 内按小时的达标情况
+type sloTracker struct {
+	SLO
+	mutex   sync.Mutex
+	minutes sloRing
+	hours   sloRing
+}
+
+func newSLOTracker(s SLO) *sloTracker {
+	return &sloTracker{
+		SLO:     s,
+		minutes: sloRing{unit: time.Minute, buckets: make([]sloBucket, sloBuckets)},
+		hours:   sloRing{unit: time.Hour, buckets: make([]sloBucket, s.Period/time.Hour)},
+	}
+}
+
+func (t *sloTracker) record(now time.Time, bad bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.minutes.add(now, bad)
+	t.hours.add(now, bad)
+}
+
+// ring 窗口对应的分桶：不超过 6h 的整分钟用分钟桶，不超过 Period 的整小时用小时桶
+func (t *sloTracker) ring(window time.Duration) (*sloRing, error) {
+	switch {
+	case window > 0 && window%time.Minute == 0 && window <= sloBuckets*time.Minute:
+		return &t.minutes, nil
+	case window > 0 && window%time.Hour == 0 && window <= t.Period:
+		return &t.hours, nil
+	}
+	return nil, fmt.Errorf("metrics: SLO %s 不支持窗口 %s，需为不超过 6h 的整分钟或不超过 %s 的整小时", t.Name, window, t.Period)
+}
+
+// BurnRate 窗口内错误预算的消耗速度，1 表示恰好在周期结束时用完
+func (t *sloTracker) BurnRate(now time.Time, window time.Duration) (float64, error) {
+
+	r, err := t.ring(window)
+	if err != nil {
+		return 0, err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return r.errorRate(now, window) / budget(t.Objective), nil
+}
+
+type sloSet struct {
+	mutex    sync.RWMutex
+	trackers []*sloTracker
+	now      func() time.Time
+
+	objective *prometheus.Desc
+	burnRate  *prometheus.Desc
+	remaining *prometheus.Desc
+}
+
+func newSLOSet(constLabels prometheus.Labels) *sloSet {
+	return &sloSet{
+		now: time.Now,
+		objective: prometheus.NewDesc(
+			"slo_objective", "SLO 目标达标比例", []string{"slo"}, constLabels,
+		),
+		burnRate: prometheus.NewDesc(
+			"slo_burn_rate", "SLO 错误预算燃烧率，1 表示恰好在周期结束时用完", []string{"slo", "window"}, constLabels,
+		),
+		remaining: prometheus.NewDesc(
+			"slo_error_budget_remaining", "错误预算周期（SLO.Period）内剩余的错误预算比例，负数表示已超支", []string{"slo"}, constLabels,
+		),
+	}
+}
+
+func (s *sloSet) record(path string, code int, d time.Duration) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.now()
+	for _, t := range s.trackers {
+		if t.match(path) {
+			bad := code >= http.StatusInternalServerError || (t.Latency > 0 && d > t.Latency)
+			t.record(now, bad)
+		}
+	}
+}
+
+func (s *sloSet) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.objective
+	ch <- s.burnRate
+	ch <- s.remaining
+}
+
+func (s *sloSet) Collect(ch chan<- prometheus.Metric) {
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	now := s.now()
+	for _, t := range s.trackers {
+		ch <- prometheus.MustNewConstMetric(s.objective, prometheus.GaugeValue, t.Objective, t.Name)
+		for _, w := range sloWindows {
+			rate, _ := t.BurnRate(now, w)
+			ch <- prometheus.MustNewConstMetric(s.burnRate, prometheus.GaugeValue, rate, t.Name, promDuration(w))
+		}
+		rate, _ := t.BurnRate(now, t.Period)
+		ch <- prometheus.MustNewConstMetric(s.remaining, prometheus.GaugeValue, 1-rate, t.Name)
+	}
+}
+
+// AddSLO 添加 SLO，由 Gin 中间件统计，导出 slo_objective、slo_burn_rate、slo_error_budget_remaining
+// 对应的 Prometheus 规则见 SLORules
+func (m *Metrics) AddSLO(slos ...SLO) error {
+
+	slos = append([]SLO(nil), slos...)
+	for i := range slos {
+		if slos[i].Period == 0 {
+			slos[i].Period = sloPeriod
+		}
+		if err := slos[i].validate(m.buckets); err != nil {
+			return err
+		}
+	}
+
+	m.slos.mutex.Lock()
+	defer m.slos.mutex.Unlock()
+
+	names := make(map[string]struct{}, len(m.slos.trackers)+len(slos))
+	for _, t := range m.slos.trackers {
+		names[t.Name] = struct{}{}
+	}
+	for _, s := range slos {
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("metrics: SLO %s 已存在", s.Name)
+		}
+		names[s.Name] = struct{}{}
+	}
+
+	for _, s := range slos {
+		m.slos.trackers = append(m.slos.trackers, newSLOTracker(s))
+	}
+	return nil
+}
+
+// BurnRate SLO 在窗口内的燃烧率
+// window 需为不超过 6h 的整分钟或不超过 SLO.Period 的整小时，否则与 SLO 不存在时一样返回错误
+func (m *Metrics) BurnRate(name string, window time.Duration) (float64, error) {
+
+	m.slos.mutex.RLock()
+	defer m.slos.mutex.RUnlock()
+
+	for _, t := range m.slos.trackers {
+		if t.Name == name {
+			return t.BurnRate(m.slos.now(), window)
+		}
+	}
+	return 0, fmt.Errorf("metrics: SLO %s 不存在", name)
+}
+
+// budget 错误预算比例，去掉浮点误差，如 0.99 -> 0.01
+func budget(objective float64) float64 {
+	return math.Round((1-objective)*1e9) / 1e9
+}
+
+// promDuration 转为 Prometheus 的时长写法，如 5m、1h、6h、30d
+func promDuration(d time.Duration) string {
+	if d%(time.Hour*24) == 0 {
+		return fmt.Sprintf("%dd", d/(time.Hour*24))
+	}
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
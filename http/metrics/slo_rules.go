@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 多窗口燃烧率告警：长窗口与短窗口同时超过阈值才告警，见 Google SRE Workbook
+var sloAlerts = []struct {
+	name        string
+	long, short string
+	burnRate    float64
+	for_        string
+	severity    string
+}{
+	{"SLOErrorBudgetBurnFast", "1h", "5m", 14.4, "2m", "page"},  // 2% 预算 / 1h
+	{"SLOErrorBudgetBurnSlow", "6h", "30m", 6, "15m", "ticket"}, // 5% 预算 / 6h
+}
+
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// SLORules 生成与 AddSLO 一致的 Prometheus 规则文件（YAML）
+// 每个 SLO 一组：各窗口及错误预算周期的错误比例与燃烧率记录规则 slo:error_ratio:rate{window}、slo:burn_rate:rate{window}，
+// 剩余错误预算 slo:error_budget_remaining，以及快慢两条燃烧率告警
+func (m *Metrics) SLORules() ([]byte, error) {
+
+	m.slos.mutex.RLock()
+	defer m.slos.mutex.RUnlock()
+
+	var f ruleFile
+	for _, t := range m.slos.trackers {
+		f.Groups = append(f.Groups, m.sloGroup(&t.SLO))
+	}
+
+	return yaml.Marshal(&f)
+}
+
+func (m *Metrics) sloGroup(s *SLO) ruleGroup {
+
+	ratio := formatFloat(budget(s.Objective))
+	labels := map[string]string{"slo": s.Name}
+	g := ruleGroup{Name: "slo-" + s.Name}
+
+	windows := sloWindows
+	if !slices.Contains(windows, s.Period) {
+		windows = append(slices.Clip(windows), s.Period)
+	}
+
+	for _, w := range windows {
+		window := promDuration(w)
+		g.Rules = append(g.Rules, rule{
+			Record: "slo:error_ratio:rate" + window,
+			Expr:   m.sloErrorRatio(s, window),
+			Labels: labels,
+		}, rule{
+			Record: "slo:burn_rate:rate" + window,
+			Expr:   fmt.Sprintf(`slo:error_ratio:rate%s{slo=%q} / %s`, window, s.Name, ratio),
+			Labels: labels,
+		})
+	}
+
+	g.Rules = append(g.Rules, rule{
+		Record: "slo:error_budget_remaining",
+		Expr:   fmt.Sprintf(`1 - slo:burn_rate:rate%s{slo=%q}`, promDuration(s.Period), s.Name),
+		Labels: labels,
+	})
+
+	for _, a := range sloAlerts {
+		g.Rules = append(g.Rules, rule{
+			Alert: a.name,
+			Expr: fmt.Sprintf(`slo:burn_rate:rate%s{slo=%q} > %s and slo:burn_rate:rate%s{slo=%q} > %s`,
+				a.long, s.Name, formatFloat(a.burnRate), a.short, s.Name, formatFloat(a.burnRate)),
+			For:    a.for_,
+			Labels: map[string]string{"slo": s.Name, "severity": a.severity},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("SLO %s 错误预算燃烧过快（%s 与 %s 窗口燃烧率均超过 %s）", s.Name, a.long, a.short, formatFloat(a.burnRate)),
+			},
+		})
+	}
+
+	return g
+}
+
+// sloErrorRatio 窗口内未达标比例的 PromQL
+func (m *Metrics) sloErrorRatio(s *SLO, window string) string {
+
+	sel := m.sloSelector(s)
+	if s.Latency > 0 {
+		return fmt.Sprintf(
+			`1 - (sum(rate(http_request_duration_seconds_bucket{%s,status!="5xx",%s}[%s])) / sum(rate(http_request_duration_seconds_count{%s}[%s])))`,
+			sel, leMatcher(s.Latency.Seconds()), window, sel, window,
+		)
+	}
+
+	return fmt.Sprintf(
+		`1 - (sum(rate(http_request_total{%s,status!="5xx"}[%s])) / sum(rate(http_request_total{%s}[%s])))`,
+		sel, window, sel, window,
+	)
+}
+
+// sloSelector 服务固定标签与路由匹配
+func (m *Metrics) sloSelector(s *SLO) string {
+
+	var matchers []string
+	for _, k := range slices.Sorted(maps.Keys(m.opts.ConstLabels)) {
+		matchers = append(matchers, fmt.Sprintf("%s=%s", k, strconv.Quote(m.opts.ConstLabels[k])))
+	}
+
+	routes := make([]string, 0, len(s.Routes))
+	for _, r := range s.Routes {
+		if prefix, ok := strings.CutSuffix(r, "*"); ok {
+			routes = append(routes, regexp.QuoteMeta(prefix)+".*")
+		} else {
+			routes = append(routes, regexp.QuoteMeta(r))
+		}
+	}
+	matchers = append(matchers, "path=~"+strconv.Quote(strings.Join(routes, "|")))
+
+	return strings.Join(matchers, ",")
+}
+
+// leMatcher 分桶 le 标签的匹配
+// 整数分桶在文本格式中为 le="1"，在 OpenMetrics 格式中为 le="1.0"，两种写法都要匹配
+func leMatcher(v float64) string {
+	s := formatFloat(v)
+	if strings.ContainsAny(s, "e.") {
+		return "le=" + strconv.Quote(s)
+	}
+	return "le=~" + strconv.Quote(regexp.QuoteMeta(s)+`(\.0)?`)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,197 @@
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSLO(t *testing.T) {
+
+	m := New("test", WithBuckets(0.1, 0.3, 1))
+
+	if err := m.AddSLO(SLO{Name: "bad", Routes: []string{"/api/*"}, Objective: 0.99, Latency: time.Millisecond * 200}); err == nil {
+		t.Fatal("Latency 不在分桶中应报错")
+	}
+	if err := m.AddSLO(
+		SLO{Name: "api-latency", Routes: []string{"/api/*"}, Objective: 0.99, Latency: time.Millisecond * 300},
+		SLO{Name: "login", Routes: []string{"/login"}, Objective: 0.9},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSLO(SLO{Name: "login", Routes: []string{"/x"}, Objective: 0.9}); err == nil {
+		t.Fatal("重名应报错")
+	}
+	if err := m.AddSLO(SLO{Name: "period", Routes: []string{"/x"}, Objective: 0.9, Period: time.Minute * 90}); err == nil {
+		t.Fatal("Period 不是整小时应报错")
+	}
+
+	now := time.Unix(1700000000, 0)
+	m.slos.now = func() time.Time { return now }
+
+	// 100 个 /api 请求：1 个 5xx，1 个超时
+	for i := range 100 {
+		code, d := http.StatusOK, time.Millisecond*10
+		switch i {
+		case 0:
+			code = http.StatusBadGateway
+		case 1:
+			d = time.Second
+		}
+		m.slos.record("/api/user/:id", code, d)
+	}
+	// login 只看可用性，慢请求不计
+	m.slos.record("/login", http.StatusOK, time.Second)
+	m.slos.record("/other", http.StatusInternalServerError, 0)
+
+	if r, _ := m.BurnRate("api-latency", time.Minute*5); math.Abs(r-2) > 1e-9 {
+		t.Errorf("api-latency burn rate = %v", r)
+	}
+	if r, _ := m.BurnRate("login", time.Minute*5); r != 0 {
+		t.Errorf("login burn rate = %v", r)
+	}
+
+	// 超出窗口后不再计入
+	now = now.Add(time.Minute * 10)
+	if r, _ := m.BurnRate("api-latency", time.Minute*5); r != 0 {
+		t.Errorf("5m 窗口外 burn rate = %v", r)
+	}
+	if r, _ := m.BurnRate("api-latency", time.Hour); math.Abs(r-2) > 1e-9 {
+		t.Errorf("1h burn rate = %v", r)
+	}
+
+	// 超过 6h 的窗口按小时分桶，最长到 Period
+	now = now.Add(time.Hour * 7)
+	if r, _ := m.BurnRate("api-latency", time.Hour*6); r != 0 {
+		t.Errorf("6h 窗口外 burn rate = %v", r)
+	}
+	if r, err := m.BurnRate("api-latency", time.Hour*24*30); err != nil || math.Abs(r-2) > 1e-9 {
+		t.Errorf("30d burn rate = %v %v", r, err)
+	}
+	for _, w := range []time.Duration{0, time.Second * 90, time.Hour*6 + time.Minute, time.Hour * 24 * 31} {
+		if _, err := m.BurnRate("api-latency", w); err == nil {
+			t.Errorf("窗口 %s 应报错", w)
+		}
+	}
+	if _, err := m.BurnRate("none", time.Hour); err == nil {
+		t.Error("SLO 不存在应报错")
+	}
+
+	// 经 Gin 中间件统计，并导出
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Gin)
+	r.GET("/metrics", gin.WrapH(m.Exporter()))
+	r.GET("/login", func(g *gin.Context) { g.Status(http.StatusServiceUnavailable) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// 剩余错误预算按 Period 计算，6h 之前的请求仍计入
+	for _, want := range []string{
+		`slo_objective{slo="api-latency",svc="test"} 0.99`,
+		`slo_burn_rate{slo="login",svc="test",window="5m"} 10`,
+		`slo_burn_rate{slo="api-latency",svc="test",window="6h"} 0`,
+		`slo_error_budget_remaining{slo="api-latency",svc="test"} -1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("缺少 %s", want)
+		}
+	}
+}
+
+func TestSLORules(t *testing.T) {
+
+	m := New("test", WithBuckets(0.1, 0.3, 1))
+	m.AddSLO(
+		SLO{Name: "api-latency", Routes: []string{"/api/*", "/v1.0/ping"}, Objective: 0.99, Latency: time.Millisecond * 300},
+		SLO{Name: "login", Routes: []string{"/login"}, Objective: 0.999, Period: time.Hour * 24 * 7},
+	)
+
+	b, err := m.SLORules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var f ruleFile
+	if err = yaml.Unmarshal(b, &f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Groups) != 2 || len(f.Groups[0].Rules) != (len(sloWindows)+1)*2+1+len(sloAlerts) {
+		t.Fatalf("rules:\n%s", b)
+	}
+
+	s := string(b)
+	for _, want := range []string{
+		`record: slo:error_ratio:rate5m`,
+		`1 - (sum(rate(http_request_duration_seconds_bucket{svc="test",path=~"/api/.*|/v1\\.0/ping",status!="5xx",le="0.3"}[5m])) / sum(rate(http_request_duration_seconds_count{svc="test",path=~"/api/.*|/v1\\.0/ping"}[5m])))`,
+		`slo:error_ratio:rate1h{slo="login"} / 0.001`,
+		`slo:burn_rate:rate1h{slo="api-latency"} > 14.4 and slo:burn_rate:rate5m{slo="api-latency"} > 14.4`,
+		`1 - (sum(rate(http_request_total{svc="test",path=~"/login",status!="5xx"}[6h]))`,
+		`record: slo:burn_rate:rate30d`,
+		`1 - slo:burn_rate:rate30d{slo="api-latency"}`,
+		`1 - slo:burn_rate:rate7d{slo="login"}`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("缺少 %s\n%s", want, s)
+		}
+	}
+}
+
+// 整数分桶的 le 在文本格式与 OpenMetrics 格式中写法不同，规则需都能匹配
+func TestSLORulesOpenMetrics(t *testing.T) {
+
+	m := New("test", WithBuckets(0.1, 0.3, 1))
+	if err := m.AddSLO(SLO{Name: "api", Routes: []string{"/api/*"}, Objective: 0.99, Latency: time.Second}); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := m.SLORules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f ruleFile
+	if err = yaml.Unmarshal(b, &f); err != nil {
+		t.Fatal(err)
+	}
+
+	// 取出规则中的 le 匹配，与 Prometheus 一样整体匹配
+	sub := regexp.MustCompile(`le=~("(?:[^"\\]|\\.)*")`).FindStringSubmatch(f.Groups[0].Rules[0].Expr)
+	if sub == nil {
+		t.Fatalf("缺少 le=~：%s", f.Groups[0].Rules[0].Expr)
+	}
+	pattern, err := strconv.Unquote(sub[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	le := regexp.MustCompile("^(?:" + pattern + ")$")
+
+	h := m.Exporter()
+	m.ObserveRequestDurationWithStatus(http.MethodGet, "/api/x", "2xx", time.Millisecond)
+	for _, accept := range []string{
+		"application/openmetrics-text; version=1.0.0; charset=utf-8",
+		"text/plain; version=0.0.4",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		var matched []string
+		for _, v := range regexp.MustCompile(`http_request_duration_seconds_bucket\{[^}]*le="([^"]+)"`).FindAllStringSubmatch(w.Body.String(), -1) {
+			if le.MatchString(v[1]) {
+				matched = append(matched, v[1])
+			}
+		}
+		if len(matched) != 1 {
+			t.Errorf("%s: le 匹配 %v", accept, matched)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/jack0829/letsgo/common/limiter"
+	"github.com/jack0829/letsgo/http/breaker"
+	"github.com/jack0829/letsgo/http/signature"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Middleware 包装 RoundTripper，用于组合出站请求的处理逻辑
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain 按顺序叠加中间件，第一个在最外层；base 为空时使用 http.DefaultTransport
+func Chain(base http.RoundTripper, mws ...Middleware) http.RoundTripper {
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i] != nil {
+			base = mws[i](base)
+		}
+	}
+
+	return base
+}
+
+// Headers 设置固定请求头
+func Headers(headers map[string]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			for k, v := range headers {
+				req.Header.Set(k, v)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// Sign 给请求添加签名
+func Sign(s *signature.Signature) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			s.Sign(req)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// Dump 将请求与响应的首行和 Body 写入 w，用于调试
+func Dump(w io.Writer) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (resp *http.Response, err error) {
+
+			// debug request
+			fmt.Fprintln(w, req.Method, req.URL.RequestURI())
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					bufio.NewReader(body).WriteTo(w)
+					fmt.Fprintln(w)
+					body.Close()
+				}
+			}
+
+			if resp, err = next.RoundTrip(req); err != nil {
+				fmt.Fprintln(w, err.Error())
+				return
+			}
+
+			// debug response
+			fmt.Fprintln(w, resp.Proto, resp.Status)
+			if body := resp.Body; body != nil {
+				buf := bytes.NewBuffer(nil)
+				bufio.NewReader(body).WriteTo(io.MultiWriter(buf, w))
+				fmt.Fprintln(w)
+				body.Close()
+				resp.Body = io.NopCloser(buf)
+			}
+			return
+		})
+	}
+}
+
+// Adaptive 自适应并发限制，见 AdaptiveRoundTripper
+func Adaptive(l *limiter.Adaptive) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return AdaptiveRoundTripper(next, l)
+	}
+}
+
+// Timeout 单次请求超时，含读取响应 Body 的时间
+// 与 Retry 同用时放在 Retry 之后即为每次尝试的超时
+func Timeout(d time.Duration) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+			ctx, cancel := context.WithTimeout(req.Context(), d)
+			resp, err := next.RoundTrip(req.WithContext(ctx))
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			// Body 读完或关闭后才释放 ctx
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		})
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// ErrBreakerOpen 熔断中，请求未发出
+var ErrBreakerOpen = breaker.ErrOpen
+
+// Breaker 按 host 连续失败 threshold 次后熔断 cooldown，之后放行一个请求试探，成功即恢复
+// 需要失败率、事件或指标时使用 CircuitBreaker
+func Breaker(threshold int, cooldown time.Duration) Middleware {
+	return CircuitBreaker(breaker.New(
+		breaker.WithConsecutiveFailures(threshold),
+		breaker.WithErrorRate(0, 0, 0),
+		breaker.WithCooldown(cooldown),
+	))
+}
+
+// CircuitBreaker 使用已配置的熔断器，见 breaker.New
+func CircuitBreaker(b *breaker.Breaker) Middleware {
+	return b.RoundTripper
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		order = append(order, "base")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	Chain(base, mark("a"), nil, mark("b")).RoundTrip(req)
+
+	if strings.Join(order, ",") != "a,b,base" {
+		t.Fatalf("order %v", order)
+	}
+}
+
+func TestRetry(t *testing.T) {
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	cl := &http.Client{
+		Transport: Chain(nil, Retry(RetryAttempts(3), RetryBackoff(time.Millisecond, time.Millisecond*5))),
+	}
+
+	// PUT 幂等，Body 可重放
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, bytes.NewBufferString("payload"))
+	resp, err := cl.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "payload" || calls != 3 {
+		t.Fatalf("body %q calls %d", body, calls)
+	}
+
+	// POST 不重试
+	calls = 0
+	resp, _ = cl.Post(srv.URL, "text/plain", strings.NewReader("x"))
+	resp.Body.Close()
+	if calls != 1 || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("post calls %d", calls)
+	}
+
+	// 带 Idempotency-Key 的 POST 重试
+	calls = 0
+	req, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("x"))
+	req.Header.Set(HeaderIdempotencyKey, "k1")
+	resp, _ = cl.Do(req)
+	resp.Body.Close()
+	if calls != 3 || resp.StatusCode != http.StatusOK {
+		t.Fatalf("idempotency key calls %d status %d", calls, resp.StatusCode)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	cl := &http.Client{Transport: Chain(nil, Timeout(time.Millisecond*20))}
+	_, err := cl.Get(srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect deadline, got %v", err)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+
+	var calls int32
+	fail := int32(1)
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) == 1 {
+			return nil, errors.New("down")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+
+	rt := Chain(base, Breaker(3, time.Millisecond*30))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+
+	for i := 0; i < 5; i++ {
+		rt.RoundTrip(req)
+	}
+	if _, err := rt.RoundTrip(req); err != ErrBreakerOpen || calls != 3 {
+		t.Fatalf("err %v calls %d", err, calls)
+	}
+
+	time.Sleep(time.Millisecond * 40)
+	atomic.StoreInt32(&fail, 0)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("probe %v", err)
+	}
+	if _, err := rt.RoundTrip(req); err != nil || calls != 5 {
+		t.Fatalf("closed %v calls %d", err, calls)
+	}
+}
+
+func TestTransport(t *testing.T) {
+
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-App") + "|" + r.Header.Get("Signature")))
+	}))
+	defer srv.Close()
+
+	tr := &Transport{}
+	tr.SetHeader("X-App", "letsgo")
+	tr.SetSignature("secret")
+	tr.Use(Retry(RetryBackoff(time.Millisecond, time.Millisecond)))
+
+	var dump bytes.Buffer
+	tr.Debug(&dump)
+
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	parts := strings.Split(string(body), "|")
+	if parts[0] != "letsgo" || parts[1] == "" || calls != 2 {
+		t.Fatalf("body %q calls %d", body, calls)
+	}
+
+	// 每次尝试都经过调试输出
+	if n := strings.Count(dump.String(), "GET /"); n != 2 {
+		t.Fatalf("dump %d requests:\n%s", n, dump.String())
+	}
+}
+
+// 中间件只在首次请求和配置变化后组装
+func TestTransportChain(t *testing.T) {
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-App")))
+	}))
+	defer srv.Close()
+
+	var built int32
+	counted := func(next http.RoundTripper) http.RoundTripper {
+		atomic.AddInt32(&built, 1)
+		return next
+	}
+
+	tr := (&Transport{}).Use(counted)
+	cl := &http.Client{Transport: tr}
+	get := func() string {
+		resp, err := cl.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b)
+	}
+
+	for i := 0; i < 3; i++ {
+		get()
+	}
+	if built != 1 {
+		t.Fatalf("built %d times", built)
+	}
+
+	tr.SetHeader("X-App", "letsgo")
+	if got := get(); got != "letsgo" || built != 2 {
+		t.Fatalf("got %q, built %d times", got, built)
+	}
+	get()
+	if built != 2 {
+		t.Fatalf("built %d times", built)
+	}
+}
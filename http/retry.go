@@ -0,0 +1,154 @@
+package http
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HeaderIdempotencyKey 带此请求头的非幂等请求也会重试
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+type retry struct {
+	attempts  int
+	base, max time.Duration
+	retryable func(resp *http.Response, err error) bool
+}
+
+type RetryOption func(r *retry)
+
+// RetryAttempts 最多尝试次数（含第一次），默认 3
+func RetryAttempts(n int) RetryOption {
+	return func(r *retry) {
+		if n > 0 {
+			r.attempts = n
+		}
+	}
+}
+
+// RetryBackoff 指数退避的初始与最大间隔，默认 100ms、5s，实际间隔带随机抖动
+func RetryBackoff(base, max time.Duration) RetryOption {
+	return func(r *retry) {
+		if base > 0 {
+			r.base = base
+		}
+		if max >= r.base {
+			r.max = max
+		}
+	}
+}
+
+// RetryIf 自定义是否重试，默认网络错误、429、502、503、504 时重试
+func RetryIf(fn func(resp *http.Response, err error) bool) RetryOption {
+	return func(r *retry) {
+		r.retryable = fn
+	}
+}
+
+func defaultRetryable(resp *http.Response, err error) bool {
+
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Retry 失败时退避重试
+// 仅重试幂等方法或带 Idempotency-Key 的请求，且 Body 需可重放（无 Body 或设置了 GetBody）
+// 响应带 Retry-After（秒）时按其等待，不超过最大间隔
+func Retry(ops ...RetryOption) Middleware {
+
+	r := &retry{
+		attempts:  3,
+		base:      time.Millisecond * 100,
+		max:       time.Second * 5,
+		retryable: defaultRetryable,
+	}
+
+	for _, op := range ops {
+		op(r)
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+			if !replayable(req) {
+				return next.RoundTrip(req)
+			}
+
+			for i := 1; ; i++ {
+
+				attempt := req
+				if i > 1 && req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attempt = req.Clone(req.Context())
+					attempt.Body = body
+				}
+
+				resp, err := next.RoundTrip(attempt)
+				if i >= r.attempts || !r.retryable(resp, err) || req.Context().Err() != nil {
+					return resp, err
+				}
+
+				wait := r.backoff(i, resp)
+				if resp != nil {
+					// 丢弃 Body 以便复用连接
+					io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+					resp.Body.Close()
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					return nil, req.Context().Err()
+				case <-timer.C:
+				}
+			}
+		})
+	}
+}
+
+func replayable(req *http.Request) bool {
+
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+// backoff 第 n 次失败后的等待时长
+func (r *retry) backoff(n int, resp *http.Response) time.Duration {
+
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			return min(time.Duration(s)*time.Second, r.max)
+		}
+	}
+
+	d := r.base << (n - 1)
+	if d > r.max || d <= 0 {
+		d = r.max
+	}
+
+	// 在 [d/2, d) 间抖动，避免多个客户端同时重试
+	return d/2 + rand.N(d/2+1)
+}
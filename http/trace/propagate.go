@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RoundTripper 出站请求携带 traceparent，加入调用方的链路（ctx 中没有则开始新链路）
+// 每次请求作为一个子 span；next 为空时使用 http.DefaultTransport
+func RoundTripper(next http.RoundTripper) http.RoundTripper {
+
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		ctx, sc := Start(req.Context())
+		req = req.Clone(ctx)
+		Inject(req.Header, sc)
+		return next.RoundTrip(req)
+	})
+}
+
+type roundTripper func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// Gin 中间件，读取入站请求的 traceparent 并开始子 span，放入 g.Request 的 ctx
+// 之后用该 ctx 发出的请求经过 RoundTripper 即加入同一链路
+func Gin(g *gin.Context) {
+
+	ctx := g.Request.Context()
+	if sc, ok := Extract(g.Request.Header); ok {
+		ctx = NewContext(ctx, sc)
+	}
+
+	ctx, _ = Start(ctx)
+	g.Request = g.Request.WithContext(ctx)
+	g.Next()
+}
@@ -0,0 +1,163 @@
+package trace
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+)
+
+// W3C Trace Context 请求头
+const (
+	HeaderTraceParent = "Traceparent"
+	HeaderTraceState  = "Tracestate"
+)
+
+// FlagSampled 上游要求采样
+const FlagSampled byte = 0x01
+
+var ErrInvalid = fmt.Errorf("trace: traceparent 格式错误")
+
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+func (id SpanID) IsValid() bool  { return id != SpanID{} }
+
+// SpanContext 一次调用在链路中的位置
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+	State   string // tracestate，原样透传
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// String traceparent 格式：00-{trace-id}-{parent-id}-{flags}
+func (sc SpanContext) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// Child 同一链路下的新 span
+func (sc SpanContext) Child() SpanContext {
+	sc.SpanID = newSpanID()
+	return sc
+}
+
+// New 新链路，默认采样
+func New() SpanContext {
+
+	var tid TraceID
+	for !tid.IsValid() {
+		binary.BigEndian.PutUint64(tid[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(tid[8:], rand.Uint64())
+	}
+
+	return SpanContext{
+		TraceID: tid,
+		SpanID:  newSpanID(),
+		Flags:   FlagSampled,
+	}
+}
+
+func newSpanID() (sid SpanID) {
+	for !sid.IsValid() {
+		binary.BigEndian.PutUint64(sid[:], rand.Uint64())
+	}
+	return
+}
+
+// Parse 解析 traceparent
+// 未来版本（非 00）按规范只读取前四段
+func Parse(s string) (sc SpanContext, err error) {
+
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 ||
+		len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == "00" && len(parts) != 4) ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, ErrInvalid
+	}
+
+	var flags [1]byte
+	if _, err = hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, ErrInvalid
+	}
+	if _, err = hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, ErrInvalid
+	}
+	if _, err = hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, ErrInvalid
+	}
+	// 规范要求小写十六进制
+	if strings.ToLower(s) != s || !sc.IsValid() {
+		return SpanContext{}, ErrInvalid
+	}
+
+	sc.Flags = flags[0]
+	return sc, nil
+}
+
+// Extract 从请求头读取
+func Extract(h http.Header) (sc SpanContext, ok bool) {
+
+	sc, err := Parse(h.Get(HeaderTraceParent))
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	sc.State = h.Get(HeaderTraceState)
+	return sc, true
+}
+
+// Inject 写入请求头
+func Inject(h http.Header, sc SpanContext) {
+
+	h.Set(HeaderTraceParent, sc.String())
+	if sc.State != "" {
+		h.Set(HeaderTraceState, sc.State)
+	} else {
+		h.Del(HeaderTraceState)
+	}
+}
+
+type ctxKey struct{}
+
+// NewContext 将 sc 作为当前 span 放入 ctx
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, ctxKey{}, sc)
+}
+
+// FromContext 当前 span
+func FromContext(ctx context.Context) (sc SpanContext, ok bool) {
+	sc, ok = ctx.Value(ctxKey{}).(SpanContext)
+	return
+}
+
+// Start 以 ctx 中的 span 为父节点开始新 span，没有则开始新链路
+func Start(ctx context.Context) (context.Context, SpanContext) {
+
+	sc, ok := FromContext(ctx)
+	if ok && sc.IsValid() {
+		sc = sc.Child()
+	} else {
+		sc = New()
+	}
+
+	return NewContext(ctx, sc), sc
+}
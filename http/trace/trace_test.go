@@ -0,0 +1,90 @@
+package trace
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	const s = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.String() != s || !sc.Sampled() {
+		t.Fatalf("sc = %s", sc)
+	}
+
+	// 未来版本可带更多字段
+	if _, err = Parse("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Error(err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		if _, err = Parse(bad); err == nil {
+			t.Errorf("%q 应解析失败", bad)
+		}
+	}
+}
+
+func TestPropagate(t *testing.T) {
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	// 下游收到的 traceparent
+	var got SpanContext
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = Extract(r.Header)
+	}))
+	defer downstream.Close()
+
+	cl := &http.Client{Transport: RoundTripper(nil)}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Gin)
+	r.GET("/", func(g *gin.Context) {
+		req, _ := http.NewRequestWithContext(g.Request.Context(), http.MethodGet, downstream.URL, nil)
+		resp, err := cl.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceParent, parent)
+	req.Header.Set(HeaderTraceState, "k=v")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	p, _ := Parse(parent)
+	if got.TraceID != p.TraceID || got.SpanID == p.SpanID || !got.SpanID.IsValid() {
+		t.Fatalf("got = %s", got)
+	}
+	if got.State != "k=v" {
+		t.Errorf("state = %q", got.State)
+	}
+
+	// 没有上游链路时开始新链路
+	ctx, sc := Start(context.Background())
+	if !sc.IsValid() || !sc.Sampled() {
+		t.Fatalf("sc = %s", sc)
+	}
+	if cur, _ := FromContext(ctx); cur != sc {
+		t.Errorf("ctx span = %s", cur)
+	}
+}
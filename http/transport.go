@@ -1,85 +1,122 @@
 package http
 
 import (
-	"bufio"
-	"bytes"
-	"fmt"
+	"github.com/jack0829/letsgo/common/limiter"
 	"github.com/jack0829/letsgo/http/signature"
 	"io"
+	"maps"
 	"net/http"
+	"sync"
 )
 
+// Transport 在 http.Transport 外依次经过：固定请求头、Use 添加的中间件、签名、日志与调试输出、自适应并发限制
 type Transport struct {
 	http.Transport
-	debugger   io.Writer
-	setHeaders map[string]string
-	signature  *signature.Signature
+	debugger    io.Writer
+	logger      Middleware
+	setHeaders  map[string]string
+	signature   *signature.Signature
+	adaptive    *limiter.Adaptive
+	middlewares []Middleware
+	mutex       sync.Mutex
+	chain       http.RoundTripper // 首次请求时组装，配置变化后重新组装
 }
 
 func (t *Transport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	return t.handler().RoundTrip(req)
+}
 
-	if t.setHeaders != nil {
-		for k, v := range t.setHeaders {
-			req.Header.Set(k, v)
-		}
+func (t *Transport) handler() http.RoundTripper {
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.chain != nil {
+		return t.chain
 	}
 
-	t.Sign(req)
+	mws := make([]Middleware, 0, len(t.middlewares)+5)
+	if t.setHeaders != nil {
+		mws = append(mws, Headers(maps.Clone(t.setHeaders)))
+	}
+	mws = append(mws, t.middlewares...)
+	if t.signature != nil {
+		mws = append(mws, Sign(t.signature))
+	}
+	if t.logger != nil {
+		mws = append(mws, t.logger)
+	}
+	if t.debugger != nil {
+		mws = append(mws, Dump(t.debugger))
+	}
+	if t.adaptive != nil {
+		mws = append(mws, Adaptive(t.adaptive))
+	}
 
-	if w := t.debugger; w != nil {
+	t.chain = Chain(&t.Transport, mws...)
+	return t.chain
+}
 
-		// debug request
-		fmt.Fprintln(w, req.Method, req.URL.RequestURI())
-		if req.GetBody != nil {
-			if body, err := req.GetBody(); err == nil {
-				bufio.NewReader(body).WriteTo(w)
-				fmt.Fprintln(w)
-				body.Close()
-			}
-		}
+// update 修改配置，下次请求时重新组装
+func (t *Transport) update(fn func()) *Transport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	fn()
+	t.chain = nil
+	return t
+}
 
-		// debug response
-		defer func() {
-
-			if err != nil {
-				fmt.Fprintln(w, err.Error())
-				return
-			}
-
-			fmt.Fprintln(w, resp.Proto, resp.Status)
-			if body := resp.Body; body != nil {
-				buf := bytes.NewBuffer(nil)
-				bufio.NewReader(body).WriteTo(io.MultiWriter(buf, w))
-				fmt.Fprintln(w)
-				body.Close()
-				resp.Body = io.NopCloser(buf)
-			}
-		}()
-	}
+// Use 添加中间件，按添加顺序由外到内执行，位于固定请求头之后、签名之前
+// 例如 Retry 放在这里，每次重试都会重新签名
+func (t *Transport) Use(mws ...Middleware) *Transport {
+	return t.update(func() {
+		t.middlewares = append(t.middlewares, mws...)
+	})
+}
 
-	return t.Transport.RoundTrip(req)
+// Log 结构化记录请求与响应，位于签名之后，见 Log
+func (t *Transport) Log(ops ...LogOption) *Transport {
+	return t.update(func() {
+		t.logger = Log(ops...)
+	})
 }
 
+// Debug 将原始请求与响应写入 w
+//
+// Deprecated: 使用 Log
 func (t *Transport) Debug(w io.Writer) *Transport {
-	t.debugger = w
-	return t
+	return t.update(func() {
+		t.debugger = w
+	})
 }
 
 func (t *Transport) SetHeader(k, v string) {
+	t.update(func() {
+		if t.setHeaders == nil {
+			t.setHeaders = make(map[string]string)
+		}
+		t.setHeaders[k] = v
+	})
+}
 
-	if t.setHeaders == nil {
-		t.setHeaders = make(map[string]string)
-	}
-
-	t.setHeaders[k] = v
+// SetAdaptive 按下游耗时与错误自动调整并发上限
+func (t *Transport) SetAdaptive(l *limiter.Adaptive) {
+	t.update(func() {
+		t.adaptive = l
+	})
 }
 
 func (t *Transport) SetSignature(secret string) {
-	t.signature = signature.New(secret)
+	t.update(func() {
+		t.signature = signature.New(secret)
+	})
 }
 
 func (t *Transport) Sign(req *http.Request) {
-	if s := t.signature; s != nil {
+	t.mutex.Lock()
+	s := t.signature
+	t.mutex.Unlock()
+	if s != nil {
 		s.Sign(req)
 	}
 }
@@ -0,0 +1,14 @@
+package kafka
+
+import (
+	"context"
+	KAFKA "github.com/segmentio/kafka-go"
+)
+
+// Consumer 消息消费者，*kafka.Reader 即满足此接口
+type Consumer interface {
+	ReadMessage(ctx context.Context) (KAFKA.Message, error)
+	FetchMessage(ctx context.Context) (KAFKA.Message, error)
+	CommitMessages(ctx context.Context, msgs ...KAFKA.Message) error
+	Close() error
+}
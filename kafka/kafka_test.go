@@ -47,7 +47,9 @@ func TestMain(m *testing.M) {
 
 	conn, err := dialer.DialContext(ctx, "tcp", hosts[0])
 	if err != nil {
+		// 无 broker 时只运行内存实现的测试
 		fmt.Fprintln(os.Stderr, err)
+		m.Run()
 		return
 	}
 	defer conn.Close()
@@ -74,6 +76,10 @@ func TestMain(m *testing.M) {
 
 func TestKafka(t *testing.T) {
 
+	if writer == nil {
+		t.Skip("kafka broker 不可用")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
@@ -0,0 +1,458 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	KAFKA "github.com/segmentio/kafka-go"
+	"sync"
+	"time"
+)
+
+var (
+	errOnlyAvailableWithGroup = fmt.Errorf("unavailable when GroupID is not set")
+	errClosed                 = fmt.Errorf("kafka: 已关闭")
+)
+
+// Broker 进程内的 Kafka 替身，用于无 broker 环境下的单元测试
+// 支持 Topic、分区、按 Key 分区和消费组位移，语义与 kafka-go 保持一致
+type Broker struct {
+	mutex      sync.Mutex
+	partitions int
+	balancer   KAFKA.Balancer
+	topics     map[string]*memoryTopic
+	groups     map[string]*memoryGroup // key: groupID + "/" + topic
+	notify     chan struct{}           // 有新消息时关闭并替换
+}
+
+type BrokerOption func(b *Broker)
+
+type memoryTopic struct {
+	partitions [][]KAFKA.Message
+}
+
+// memoryGroup 分区按成员分配，每个分区同一时刻只属于一个成员
+type memoryGroup struct {
+	committed []int64         // 已提交位移（下一条待消费）
+	fetched   []int64         // 已分发位移（下一条待分发）
+	owners    []*MemoryReader // 各分区所属成员
+	members   []*MemoryReader
+	next      int // 轮询分区
+}
+
+func NewBroker(ops ...BrokerOption) *Broker {
+
+	b := &Broker{
+		partitions: 1,
+		balancer:   &KAFKA.Hash{},
+		topics:     make(map[string]*memoryTopic),
+		groups:     make(map[string]*memoryGroup),
+		notify:     make(chan struct{}),
+	}
+
+	for _, op := range ops {
+		op(b)
+	}
+
+	return b
+}
+
+// WithPartitions 自动创建 Topic 时的分区数，默认 1
+func WithPartitions(n int) BrokerOption {
+	return func(b *Broker) {
+		if n > 0 {
+			b.partitions = n
+		}
+	}
+}
+
+// WithBalancer 分区策略，默认与 kafka-go 相同的按 Key 哈希
+func WithBalancer(balancer KAFKA.Balancer) BrokerOption {
+	return func(b *Broker) {
+		if balancer != nil {
+			b.balancer = balancer
+		}
+	}
+}
+
+// CreateTopic 创建 Topic，已存在时忽略
+func (b *Broker) CreateTopic(topic string, partitions int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.topic(topic, partitions)
+}
+
+func (b *Broker) topic(name string, partitions int) *memoryTopic {
+
+	if t, ok := b.topics[name]; ok {
+		return t
+	}
+
+	if partitions < 1 {
+		partitions = b.partitions
+	}
+
+	t := &memoryTopic{
+		partitions: make([][]KAFKA.Message, partitions),
+	}
+	b.topics[name] = t
+	return t
+}
+
+func (b *Broker) group(id, topic string) *memoryGroup {
+
+	key := id + "/" + topic
+	if g, ok := b.groups[key]; ok {
+		return g
+	}
+
+	n := len(b.topic(topic, 0).partitions)
+	g := &memoryGroup{
+		committed: make([]int64, n),
+		fetched:   make([]int64, n),
+		owners:    make([]*MemoryReader, n),
+	}
+	b.groups[key] = g
+	return g
+}
+
+// join 加入成员并重新分配分区
+func (g *memoryGroup) join(r *MemoryReader) {
+	g.members = append(g.members, r)
+	g.rebalance()
+}
+
+// leave 移除成员，仅其名下的分区回到已提交位移并分给其他成员
+func (g *memoryGroup) leave(r *MemoryReader) {
+
+	for i, m := range g.members {
+		if m == r {
+			g.members = append(g.members[:i], g.members[i+1:]...)
+			break
+		}
+	}
+
+	for p, owner := range g.owners {
+		if owner == r {
+			g.owners[p] = nil
+		}
+	}
+	g.rebalance()
+}
+
+// rebalance 粘性分配：无主分区分给分区最少的成员，再从最多的成员移出直至相差不超过 1
+// 换了成员的分区从已提交位移开始分发，未换的分区不受影响
+func (g *memoryGroup) rebalance() {
+
+	if len(g.members) < 1 {
+		for p := range g.owners {
+			g.owners[p] = nil
+			g.fetched[p] = g.committed[p]
+		}
+		return
+	}
+
+	counts := make(map[*MemoryReader]int, len(g.members))
+	for _, owner := range g.owners {
+		if owner != nil {
+			counts[owner]++
+		}
+	}
+
+	bound := func(less bool) *MemoryReader {
+		r := g.members[0]
+		for _, m := range g.members[1:] {
+			if less && counts[m] < counts[r] || !less && counts[m] > counts[r] {
+				r = m
+			}
+		}
+		return r
+	}
+
+	assign := func(p int, r *MemoryReader) {
+		if owner := g.owners[p]; owner != nil {
+			counts[owner]--
+		}
+		g.owners[p] = r
+		g.fetched[p] = g.committed[p]
+		counts[r]++
+	}
+
+	for p, owner := range g.owners {
+		if owner == nil {
+			assign(p, bound(true))
+		}
+	}
+
+	for {
+		least, most := bound(true), bound(false)
+		if counts[most]-counts[least] <= 1 {
+			return
+		}
+		for p, owner := range g.owners {
+			if owner == most {
+				assign(p, least)
+				break
+			}
+		}
+	}
+}
+
+// Messages 某分区的全部消息，用于断言
+func (b *Broker) Messages(topic string, partition int) []KAFKA.Message {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	t, ok := b.topics[topic]
+	if !ok || partition < 0 || partition >= len(t.partitions) {
+		return nil
+	}
+
+	return append([]KAFKA.Message(nil), t.partitions[partition]...)
+}
+
+// Committed 消费组在某分区已提交的位移
+func (b *Broker) Committed(groupID, topic string, partition int) int64 {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	g, ok := b.groups[groupID+"/"+topic]
+	if !ok || partition < 0 || partition >= len(g.committed) {
+		return 0
+	}
+	return g.committed[partition]
+}
+
+func (b *Broker) produce(msgs []KAFKA.Message) {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	for _, m := range msgs {
+
+		t := b.topic(m.Topic, 0)
+		partitions := make([]int, len(t.partitions))
+		for i := range partitions {
+			partitions[i] = i
+		}
+
+		p := b.balancer.Balance(m, partitions...)
+		m.Partition = p
+		m.Offset = int64(len(t.partitions[p]))
+		if m.Time.IsZero() {
+			m.Time = now
+		}
+		t.partitions[p] = append(t.partitions[p], m)
+	}
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+// Writer 创建生产者，topic 为空时由消息自行指定
+func (b *Broker) Writer(topic string) *MemoryWriter {
+	return &MemoryWriter{
+		b:     b,
+		topic: topic,
+	}
+}
+
+// Reader 创建消费者，仅使用 cfg 中的 Topic、GroupID、Partition、StartOffset
+// 使用消费组时加入该组，分区在成员间重新分配
+func (b *Broker) Reader(cfg KAFKA.ReaderConfig) *MemoryReader {
+
+	r := &MemoryReader{
+		b:         b,
+		topic:     cfg.Topic,
+		groupID:   cfg.GroupID,
+		partition: cfg.Partition,
+		done:      make(chan struct{}),
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if r.groupID != "" {
+		b.group(r.groupID, r.topic).join(r)
+		return r
+	}
+
+	switch cfg.StartOffset {
+	case KAFKA.LastOffset:
+		t := b.topic(r.topic, 0)
+		if r.partition < len(t.partitions) {
+			r.offset = int64(len(t.partitions[r.partition]))
+		}
+	case KAFKA.FirstOffset:
+	default:
+		if cfg.StartOffset > 0 {
+			r.offset = cfg.StartOffset
+		}
+	}
+
+	return r
+}
+
+type MemoryWriter struct {
+	b      *Broker
+	topic  string
+	mutex  sync.RWMutex
+	closed bool
+}
+
+func (w *MemoryWriter) WriteMessages(ctx context.Context, msgs ...KAFKA.Message) error {
+
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if w.closed {
+		return errClosed
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	list := make([]KAFKA.Message, 0, len(msgs))
+	for _, m := range msgs {
+		switch {
+		case w.topic != "" && m.Topic != "":
+			return fmt.Errorf("kafka.(*Writer): Topic must not be specified for both Writer and Message")
+		case w.topic == "" && m.Topic == "":
+			return fmt.Errorf("kafka.(*Writer): Topic must be specified for Writer or Message")
+		case m.Topic == "":
+			m.Topic = w.topic
+		}
+		list = append(list, m)
+	}
+
+	w.b.produce(list)
+	return nil
+}
+
+func (w *MemoryWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	return nil
+}
+
+type MemoryReader struct {
+	b         *Broker
+	topic     string
+	groupID   string
+	partition int
+	offset    int64 // 未使用消费组时的位移
+	once      sync.Once
+	done      chan struct{}
+}
+
+// ReadMessage 读取消息，使用消费组时自动提交
+func (r *MemoryReader) ReadMessage(ctx context.Context) (KAFKA.Message, error) {
+
+	m, err := r.FetchMessage(ctx)
+	if err != nil {
+		return m, err
+	}
+
+	if r.groupID != "" {
+		err = r.CommitMessages(ctx, m)
+	}
+	return m, err
+}
+
+// FetchMessage 读取消息，不提交位移
+func (r *MemoryReader) FetchMessage(ctx context.Context) (KAFKA.Message, error) {
+
+	for {
+
+		m, ok, notify := r.fetch()
+		if ok {
+			return m, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return KAFKA.Message{}, ctx.Err()
+		case <-r.done:
+			return KAFKA.Message{}, errClosed
+		case <-notify:
+		}
+	}
+}
+
+func (r *MemoryReader) fetch() (m KAFKA.Message, ok bool, notify <-chan struct{}) {
+
+	b := r.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	notify = b.notify
+	t := b.topic(r.topic, 0)
+
+	if r.groupID == "" {
+		if r.partition < len(t.partitions) && r.offset < int64(len(t.partitions[r.partition])) {
+			m, ok = t.partitions[r.partition][r.offset], true
+			r.offset++
+		}
+		return
+	}
+
+	g := b.group(r.groupID, r.topic)
+	n := len(t.partitions)
+	for i := 0; i < n; i++ {
+		p := (g.next + i) % n
+		if p < len(g.owners) && g.owners[p] == r && g.fetched[p] < int64(len(t.partitions[p])) {
+			m, ok = t.partitions[p][g.fetched[p]], true
+			g.fetched[p]++
+			g.next = p + 1
+			return
+		}
+	}
+
+	return
+}
+
+// CommitMessages 提交位移，仅消费组可用
+func (r *MemoryReader) CommitMessages(_ context.Context, msgs ...KAFKA.Message) error {
+
+	if r.groupID == "" {
+		return errOnlyAvailableWithGroup
+	}
+
+	b := r.b
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	g := b.group(r.groupID, r.topic)
+	for _, m := range msgs {
+		if m.Partition < len(g.committed) && m.Offset+1 > g.committed[m.Partition] {
+			g.committed[m.Partition] = m.Offset + 1
+		}
+	}
+
+	return nil
+}
+
+// Close 关闭消费者，退出消费组；其名下分区中未提交的消息会分给其他成员重新消费
+func (r *MemoryReader) Close() error {
+
+	r.once.Do(func() {
+
+		close(r.done)
+
+		if r.groupID == "" {
+			return
+		}
+
+		b := r.b
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		b.group(r.groupID, r.topic).leave(r)
+	})
+
+	return nil
+}
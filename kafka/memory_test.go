@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	KAFKA "github.com/segmentio/kafka-go"
+	"testing"
+	"time"
+)
+
+var (
+	_ Producer = (*KAFKA.Writer)(nil)
+	_ Producer = (*MemoryWriter)(nil)
+	_ Consumer = (*KAFKA.Reader)(nil)
+	_ Consumer = (*MemoryReader)(nil)
+)
+
+func TestBrokerPartition(t *testing.T) {
+
+	b := NewBroker(WithPartitions(4))
+	w := b.Writer("demo")
+	defer w.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := w.WriteMessages(ctx, KAFKA.Message{
+			Key:   []byte(fmt.Sprintf("user-%d", i%2)),
+			Value: []byte(fmt.Sprint(i)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var total int
+	for p := 0; p < 4; p++ {
+		msgs := b.Messages("demo", p)
+		total += len(msgs)
+		for i, m := range msgs {
+			if m.Offset != int64(i) || m.Partition != p {
+				t.Errorf("partition %d offset %d: %+v", p, i, m)
+			}
+			if string(m.Key) != string(msgs[0].Key) {
+				t.Errorf("partition %d has keys %s and %s", p, msgs[0].Key, m.Key)
+			}
+		}
+	}
+	if total != 10 {
+		t.Errorf("total %d", total)
+	}
+
+	if err := b.Writer("").WriteMessages(ctx, KAFKA.Message{}); err == nil {
+		t.Error("expect topic error")
+	}
+}
+
+func TestBrokerGroup(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	b := NewBroker(WithPartitions(2))
+	w := b.Writer("demo")
+	cfg := KAFKA.ReaderConfig{Topic: "demo", GroupID: "g"}
+
+	r1 := b.Reader(cfg)
+	r2 := b.Reader(cfg)
+
+	for i := 0; i < 4; i++ {
+		w.WriteMessages(ctx, KAFKA.Message{Value: []byte(fmt.Sprint(i))})
+	}
+
+	seen := make(map[string]int)
+	for i := 0; i < 2; i++ {
+		m, err := r1.ReadMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[string(m.Value)]++
+	}
+
+	// r2 取到但未提交，关闭后应重新分发
+	m, err := r2.FetchMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2.Close()
+
+	for i := 0; i < 2; i++ {
+		m, err := r1.ReadMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[string(m.Value)]++
+	}
+	if len(seen) != 4 {
+		t.Errorf("seen %v, uncommitted %s", seen, m.Value)
+	}
+
+	if c := b.Committed("g", "demo", 0) + b.Committed("g", "demo", 1); c != 4 {
+		t.Errorf("committed %d", c)
+	}
+
+	// 阻塞等待新消息
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		w.WriteMessages(ctx, KAFKA.Message{Value: []byte("late")})
+	}()
+	if m, err = r1.ReadMessage(ctx); err != nil || string(m.Value) != "late" {
+		t.Errorf("late: %s %v", m.Value, err)
+	}
+
+	r1.Close()
+	if _, err = r1.FetchMessage(ctx); err == nil {
+		t.Error("expect closed error")
+	}
+}
+
+func TestBrokerGroupClose(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	b := NewBroker(WithPartitions(2))
+	w := b.Writer("demo")
+	cfg := KAFKA.ReaderConfig{Topic: "demo", GroupID: "g"}
+
+	r1 := b.Reader(cfg)
+	r2 := b.Reader(cfg)
+	defer r1.Close()
+
+	for i := 0; i < 4; i++ {
+		w.WriteMessages(ctx, KAFKA.Message{Value: []byte(fmt.Sprint(i))})
+	}
+
+	// r1、r2 各取到一条但未提交
+	m1, err := r1.FetchMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := r2.FetchMessage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m1.Partition == m2.Partition {
+		t.Fatalf("r1、r2 分到了同一分区 %d", m1.Partition)
+	}
+	r2.Close()
+
+	// r2 的分区从已提交位移开始重新分发，r1 已取到的不再分发
+	seen := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		m, err := r1.FetchMessage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[string(m.Value)]++
+	}
+
+	if seen[string(m1.Value)] != 0 || seen[string(m2.Value)] != 1 || len(seen) != 3 {
+		t.Errorf("seen %v, r1 fetched %s, r2 fetched %s", seen, m1.Value, m2.Value)
+	}
+}
+
+func TestBrokerPartitionReader(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	b := NewBroker()
+	w := b.Writer("demo")
+	w.WriteMessages(ctx, KAFKA.Message{Value: []byte("a")}, KAFKA.Message{Value: []byte("b")})
+
+	r := b.Reader(KAFKA.ReaderConfig{Topic: "demo", StartOffset: 1})
+	defer r.Close()
+
+	m, err := r.ReadMessage(ctx)
+	if err != nil || string(m.Value) != "b" {
+		t.Fatalf("%s %v", m.Value, err)
+	}
+
+	if err = r.CommitMessages(ctx, m); err == nil {
+		t.Error("expect group error")
+	}
+}
@@ -0,0 +1,11 @@
+package kafka
+
+import (
+	"context"
+	KAFKA "github.com/segmentio/kafka-go"
+)
+
+// Producer 消息生产者，*kafka.Writer 即满足此接口
+type Producer interface {
+	WriteMessages(ctx context.Context, msgs ...KAFKA.Message) error
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/derekparker/trie"
 	"github.com/fumiama/jieba"
+	"github.com/jack0829/letsgo/common/fs"
 	"io"
 	"os"
 	"regexp"
@@ -282,16 +283,12 @@ func (g *NGram) dumpTrieNode(n *trie.Node, out io.Writer, threshold ThresholdFun
 }
 
 func (g *NGram) Dump(path string, threshold ThresholdFunc) error {
-
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	fmt.Fprintf(f, "%d\n", g.docs)
-	g.dumpTrieNode(g.tr.Root(), f, threshold)
-	return nil
+	return fs.WriteFileFunc(path, 0644, func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		fmt.Fprintf(bw, "%d\n", g.docs)
+		g.dumpTrieNode(g.tr.Root(), bw, threshold)
+		return bw.Flush()
+	})
 }
 
 func scanFile(path string, fn func(line string)) error {
@@ -10,7 +10,6 @@ import (
 	"golang.org/x/exp/utf8string"
 	"io"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -126,24 +125,19 @@ func (m *Matcher) Save(w io.Writer) error {
 // SaveToFile 保存当前词典数据到文件
 func (m *Matcher) SaveToFile(path string, withCompress bool) error {
 
-	if err := fs.MustDir(filepath.Dir(path)); err != nil {
-		return err
-	}
-
-	fd, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
-	if err != nil {
-		return err
-	}
-	defer fd.Close()
+	return fs.WriteFileFunc(path, 0644, func(w io.Writer) error {
 
-	if !withCompress {
-		return m.Save(fd)
-	}
-
-	gz := gzip.NewWriter(fd)
-	defer gz.Close()
+		if !withCompress {
+			return m.Save(w)
+		}
 
-	return m.Save(gz)
+		gz := gzip.NewWriter(w)
+		if err := m.Save(gz); err != nil {
+			gz.Close()
+			return err
+		}
+		return gz.Close()
+	})
 }
 
 // Load 加载词典数据
@@ -3,13 +3,17 @@ package openapi
 import (
 	"bytes"
 	"fmt"
+	"github.com/jack0829/letsgo/common/async"
+	"github.com/jack0829/letsgo/common/limiter"
 	"github.com/jack0829/letsgo/config"
+	FH "github.com/jack0829/letsgo/http"
+	"github.com/jack0829/letsgo/http/breaker"
+	"github.com/jack0829/letsgo/log"
 	"github.com/jack0829/letsgo/restful"
 	jsoniter "github.com/json-iterator/go"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 )
 
@@ -18,9 +22,13 @@ const (
 )
 
 type Client struct {
-	cfg config.OpenAPI
-	ats AccessTokenStorager
-	cl  *http.Client
+	cfg      config.OpenAPI
+	ats      AccessTokenStorager
+	cl       *http.Client
+	log      FH.Middleware
+	adaptive *limiter.Adaptive                  // 在所有选项之后包装 Transport，见 NewClient
+	breaker  *breaker.Breaker                   // 同上
+	flight   async.Flight[string, *AccessToken] // 合并并发刷新 token
 }
 
 func NewClient(cfg config.OpenAPI, ops ...ClientOption) *Client {
@@ -38,17 +46,39 @@ func NewClient(cfg config.OpenAPI, ops ...ClientOption) *Client {
 		fn(cl)
 	}
 
+	// 选项之后再包装，不受 WithTransport 先后顺序影响；熔断在并发限制之外，熔断时不占并发
+	if cl.adaptive != nil {
+		cl.cl.Transport = FH.AdaptiveRoundTripper(cl.cl.Transport, cl.adaptive)
+	}
+	if cl.breaker != nil {
+		cl.cl.Transport = cl.breaker.RoundTripper(cl.cl.Transport)
+	}
+
+	// Debug 时默认记录请求日志，放在最外层以便看到完整请求头（敏感值已隐藏）
+	if cl.log == nil && cfg.Debug {
+		cl.log = FH.Log()
+	}
+	if cl.log != nil {
+		cl.cl.Transport = cl.log(cl.cl.Transport)
+	}
+
 	return cl
 }
 
-func (c *Client) getAccessToken() (at *AccessToken, err error) {
+func (c *Client) getAccessToken() (*AccessToken, error) {
 
 	// 加载 token
-	at, ok := c.loadAccessToken()
-	if ok {
-		return
+	if at, ok := c.loadAccessToken(); ok {
+		return at, nil
 	}
 
+	// 并发刷新时只请求一次
+	at, err, _ := c.flight.Do(c.cfg.Client.ID, c.requestAccessToken)
+	return at, err
+}
+
+func (c *Client) requestAccessToken() (at *AccessToken, err error) {
+
 	qs := url.Values{}
 	qs.Set("grant_type", "client_credentials")
 	qs.Set("client_id", c.cfg.Client.ID)
@@ -60,9 +90,7 @@ func (c *Client) getAccessToken() (at *AccessToken, err error) {
 		return
 	}
 
-	c.debugRequest(os.Stdout, req)
 	resp, err := c.cl.Do(req)
-	c.debugResponse(os.Stdout, resp, err)
 
 	if err != nil {
 		return
@@ -80,7 +108,7 @@ func (c *Client) getAccessToken() (at *AccessToken, err error) {
 	}
 
 	at = r.Data.FixExpireAt()
-	fmt.Fprintf(os.Stdout, "Access Token 过期时间：%s\n", at.ExpiresAt)
+	c.debugf("Access Token 过期时间：%s", at.ExpiresAt)
 
 	// 保存 token
 	if err = c.saveAccessToken(at); err != nil {
@@ -96,9 +124,7 @@ func (c *Client) saveAccessToken(tk *AccessToken) error {
 		return nil
 	}
 
-	if c.cfg.Debug {
-		fmt.Fprintf(os.Stdout, "保存 AccessToken：%s\n", tk.AccessToken)
-	}
+	c.debugf("保存 AccessToken，过期时间：%s", tk.ExpiresAt)
 	return c.ats.Set(tk)
 }
 
@@ -175,66 +201,11 @@ func (c *Client) do(req *http.Request) (resp *http.Response, err error) {
 	k, v := token.Header()
 	req.Header.Set(k, v)
 
-	c.debugRequest(os.Stdout, req)
-	resp, err = c.cl.Do(req)
-	c.debugResponse(os.Stdout, resp, err)
-
-	return
-}
-
-func (c *Client) debugRequest(out io.Writer, req *http.Request) {
-
-	if !c.cfg.Debug {
-		return
-	}
-
-	if req == nil {
-		return
-	}
-
-	if req.Body == nil {
-		req.Write(out)
-		return
-	}
-
-	rc, err := req.GetBody()
-	if err != nil || rc == nil {
-		return
-	}
-	fmt.Fprintf(out, "\n")
-	req.Write(out)
-	req.Body.Close()
-	req.Body = rc
+	return c.cl.Do(req)
 }
 
-func (c *Client) debugResponse(out io.Writer, resp *http.Response, err error) {
-
-	if !c.cfg.Debug {
-		return
+func (c *Client) debugf(tpl string, args ...any) {
+	if c.cfg.Debug && log.L() != nil {
+		log.Debugf(tpl, args...)
 	}
-
-	if resp == nil {
-		return
-	}
-
-	if err != nil {
-		fmt.Fprintf(out, "Request Error: %v\n", err)
-		return
-	}
-
-	buf := bytes.NewBuffer(nil)
-	buf.ReadFrom(resp.Body)
-	resp.Body.Close()
-	br, bw := io.Pipe()
-	resp.Body = br
-	mw := io.MultiWriter(bw, out)
-	go func() {
-		defer bw.Close()
-		fmt.Fprintf(out, "\n%s %s\n", resp.Proto, resp.Status)
-		resp.Header.Write(out)
-		fmt.Fprintf(out, "\n")
-		buf.WriteTo(mw)
-		fmt.Fprintf(out, "\n")
-	}()
-
 }
@@ -1,9 +1,15 @@
 package openapi
 
 import (
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/common/limiter"
 	"github.com/jack0829/letsgo/config"
+	FH "github.com/jack0829/letsgo/http"
+	"github.com/jack0829/letsgo/http/breaker"
 	jsoniter "github.com/json-iterator/go"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"testing"
@@ -13,30 +19,24 @@ var (
 	testClient *Client
 )
 
+// testTokenStorage 内存中保存 token，避免测试之间通过文件互相影响
 type testTokenStorage struct {
-	path string
+	tk *AccessToken
 }
 
 func (s *testTokenStorage) Get() *AccessToken {
-	b, err := os.ReadFile(s.path)
-	if err != nil {
-		return nil
-	}
-	var tk AccessToken
-	if err = jsoniter.Unmarshal(b, &tk); err != nil {
-		return nil
-	}
-	return &tk
+	return s.tk
 }
 
 func (s *testTokenStorage) Set(tk *AccessToken) error {
-	b, _ := jsoniter.MarshalIndent(tk, "", "  ")
-	return os.WriteFile(s.path, b, 0755)
+	s.tk = tk
+	return nil
 }
 
+// json 按 key 排序，保证请求 Body 与录制一致
 func json(v any, pretty ...bool) (s string) {
 	if len(pretty) < 1 {
-		s, _ = jsoniter.MarshalToString(v)
+		s, _ = jsoniter.ConfigCompatibleWithStandardLibrary.MarshalToString(v)
 		return
 	}
 	b, _ := jsoniter.MarshalIndent(v, "", "  ")
@@ -44,20 +44,40 @@ func json(v any, pretty ...bool) (s string) {
 	return
 }
 
+// TestMain 默认回放 testdata/client.json，不访问真实服务
+// 重新录制：OPENAPI_RECORD=1 OPENAPI_ADDR=... OPENAPI_CLIENT_ID=... OPENAPI_CLIENT_SECRET=... go test ./openapi
 func TestMain(m *testing.M) {
 
+	record := os.Getenv("OPENAPI_RECORD") != ""
+	cassette, err := FH.NewCassette("testdata/client.json",
+		FH.CassetteRecord(record),
+		FH.CassetteScrubQuery("client_id"),
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	testClient = NewClient(config.OpenAPI{
-		Addr: "http://127.0.0.1:30084",
+		Addr: env("OPENAPI_ADDR", "http://127.0.0.1:30084"),
 		Client: config.OpenAPIClient{
-			ID:     "bKkjR84JDePBaOQe1Qdr5Voyln3wYMWN",
-			Secret: "klqIIAtxerTZq7y7MyiXG3GaPhQlQgZ8",
+			ID:     env("OPENAPI_CLIENT_ID", "test-client-id"),
+			Secret: env("OPENAPI_CLIENT_SECRET", "test-client-secret"),
 		},
 		Debug: true,
-	}, WithAccessTokenStorager(&testTokenStorage{
-		path: "./access.token",
-	}))
+	},
+		WithTransport(cassette.RoundTripper(http.DefaultTransport)),
+		WithAccessTokenStorager(&testTokenStorage{}),
+	)
+
+	os.Exit(m.Run())
+}
 
-	m.Run()
+func env(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
 }
 
 func TestClient_Token(t *testing.T) {
@@ -118,3 +138,27 @@ func TestClient_Post(t *testing.T) {
 	}
 	t.Logf("body: %s", string(b))
 }
+
+// WithAdaptiveLimit、WithBreaker 在 WithTransport 之前也生效
+func TestClientOptions(t *testing.T) {
+
+	a := limiter.NewAdaptive(nil)
+	b := breaker.New(breaker.WithConsecutiveFailures(1))
+
+	var calls, inflight int
+	tr := FH.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		inflight = a.Inflight()
+		return nil, errors.New("unreachable")
+	})
+
+	c := NewClient(config.OpenAPI{Addr: "http://openapi.test"}, WithAdaptiveLimit(a), WithBreaker(b), WithTransport(tr))
+	c.cl.Get("http://openapi.test/")
+
+	if _, err := c.cl.Get("http://openapi.test/"); !errors.Is(err, breaker.ErrOpen) {
+		t.Fatalf("expect breaker open, got %v", err)
+	}
+	if calls != 1 || inflight != 1 {
+		t.Fatalf("calls %d, inflight %d", calls, inflight)
+	}
+}
@@ -1,6 +1,9 @@
 package openapi
 
 import (
+	"github.com/jack0829/letsgo/common/limiter"
+	FH "github.com/jack0829/letsgo/http"
+	"github.com/jack0829/letsgo/http/breaker"
 	"net/http"
 	"time"
 )
@@ -24,3 +27,24 @@ func WithAccessTokenStorager(ats AccessTokenStorager) ClientOption {
 		c.ats = ats
 	}
 }
+
+// WithAdaptiveLimit 按下游耗时与错误自动调整并发，与 WithTransport 的先后顺序无关
+func WithAdaptiveLimit(l *limiter.Adaptive) ClientOption {
+	return func(c *Client) {
+		c.adaptive = l
+	}
+}
+
+// WithBreaker 开放平台不可用时熔断，在并发限制之外，与 WithTransport 的先后顺序无关
+func WithBreaker(b *breaker.Breaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
+// WithLog 记录请求日志，见 FH.Log；未设置时 cfg.Debug 开启则使用默认配置
+func WithLog(ops ...FH.LogOption) ClientOption {
+	return func(c *Client) {
+		c.log = FH.Log(ops...)
+	}
+}
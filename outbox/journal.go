@@ -0,0 +1,486 @@
+package outbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/common/fs"
+	jsoniter "github.com/json-iterator/go"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrDuplicate 消息 ID 已在日志中（待发送或已放弃），或同一批中重复
+var ErrDuplicate = errors.New("outbox: 消息 ID 重复")
+
+// Journal 发件箱持久化日志
+type Journal interface {
+	// Append 原子写入一批消息，有 ID 重复时返回 ErrDuplicate，整批不写入
+	Append(msgs ...*Message) error
+	// Ack 标记消息已发送
+	Ack(ids ...string) error
+	// Update 保存待发送消息的最新状态（重试次数等），已不在待发送中的消息忽略
+	// 日志持有传入的消息，调用方此后不应再修改
+	Update(msgs ...*Message) error
+	// Bury 放弃投递：从待发送移入已放弃，连同当前状态保存
+	Bury(msgs ...*Message) error
+	// Revive 将已放弃的消息移回待发送，重试次数清零，返回移回的消息
+	Revive(ids ...string) ([]*Message, error)
+	// Pending 全部待发送消息，按写入顺序
+	Pending() []*Message
+	// Dead 全部已放弃的消息，按放弃顺序
+	Dead() []*Message
+	Close() error
+}
+
+const (
+	opAppend = "append"
+	opAck    = "ack"
+	opUpdate = "update"
+	opBury   = "bury"
+	opRevive = "revive"
+)
+
+// 压缩阈值：失效记录数（已确认、状态已更新）超过此值时重写日志
+const compactThreshold = 1024
+
+type record struct {
+	Op       string     `json:"op"`
+	Messages []*Message `json:"messages,omitempty"`
+	IDs      []string   `json:"ids,omitempty"`
+}
+
+// pending 按写入顺序保存消息
+// 确认时不从 order 中删除，pos 记录消息当前所在的下标，list 时跳过失效的位置
+type pending struct {
+	data  map[string]*Message
+	pos   map[string]int
+	order []string
+}
+
+func (p *pending) add(m *Message) bool {
+	if p.data == nil {
+		p.data = make(map[string]*Message)
+		p.pos = make(map[string]int)
+	}
+	if _, ok := p.data[m.ID]; ok {
+		return false
+	}
+	p.data[m.ID] = m
+	p.pos[m.ID] = len(p.order)
+	p.order = append(p.order, m.ID)
+	return true
+}
+
+// update 替换消息，保持原有顺序
+func (p *pending) update(m *Message) bool {
+	if _, ok := p.data[m.ID]; !ok {
+		return false
+	}
+	p.data[m.ID] = m
+	return true
+}
+
+func (p *pending) ack(id string) bool {
+	if _, ok := p.data[id]; !ok {
+		return false
+	}
+	delete(p.data, id)
+	delete(p.pos, id)
+	return true
+}
+
+func (p *pending) list() []*Message {
+	list := make([]*Message, 0, len(p.data))
+	order := make([]string, 0, len(p.data))
+	for i, id := range p.order {
+		if m, ok := p.data[id]; ok && p.pos[id] == i {
+			p.pos[id] = len(order)
+			list = append(list, m)
+			order = append(order, id)
+		}
+	}
+	p.order = order
+	return list
+}
+
+// state 待发送与已放弃的消息
+type state struct {
+	p, dead pending
+}
+
+func (s *state) apply(r *record) {
+	switch r.Op {
+	case opAppend:
+		for _, m := range r.Messages {
+			s.p.add(m)
+		}
+	case opAck:
+		for _, id := range r.IDs {
+			s.p.ack(id)
+		}
+	case opUpdate:
+		for _, m := range r.Messages {
+			s.p.update(m)
+		}
+	case opBury:
+		for _, m := range r.Messages {
+			s.p.ack(m.ID)
+			s.dead.add(m)
+		}
+	case opRevive:
+		for _, id := range r.IDs {
+			if m, ok := s.dead.data[id]; ok {
+				// Dead 返回的消息可能仍被调用方持有，在副本上清零
+				n := *m
+				n.Status.Tries = 0
+				s.dead.ack(id)
+				s.p.add(&n)
+			}
+		}
+	}
+}
+
+// checkAppend 校验整批消息的 ID
+func (s *state) checkAppend(msgs []*Message) error {
+	seen := make(map[string]struct{}, len(msgs))
+	for _, m := range msgs {
+		_, p := s.p.data[m.ID]
+		_, d := s.dead.data[m.ID]
+		_, dup := seen[m.ID]
+		if p || d || dup {
+			return fmt.Errorf("%w: %s", ErrDuplicate, m.ID)
+		}
+		seen[m.ID] = struct{}{}
+	}
+	return nil
+}
+
+// pendingIDs 过滤出待发送的消息
+func (s *state) pendingIDs(msgs []*Message) (list []*Message) {
+	for _, m := range msgs {
+		if _, ok := s.p.data[m.ID]; ok {
+			list = append(list, m)
+		}
+	}
+	return
+}
+
+// deadIDs 过滤出已放弃的 ID
+func (s *state) deadIDs(ids []string) (list []string) {
+	for _, id := range ids {
+		if _, ok := s.dead.data[id]; ok {
+			list = append(list, id)
+		}
+	}
+	return
+}
+
+func (s *state) revived(ids []string) []*Message {
+	list := make([]*Message, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, s.p.data[id])
+	}
+	return list
+}
+
+type fileJournal struct {
+	mutex sync.Mutex
+	path  string
+	fp    *os.File
+	lock  *fs.Lock
+	s     state
+	stale int
+}
+
+// FileJournal 基于本地文件的日志（JSON Lines），每次写入后 fsync
+// 同一文件只允许一个进程打开，其他进程返回 fs.ErrLocked
+func FileJournal(path string) (Journal, error) {
+
+	if err := fs.MustDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	lock, err := fs.TryLockFile(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+
+	j := &fileJournal{
+		path: path,
+		lock: lock,
+	}
+
+	if err = j.load(); err == nil {
+		err = j.compact()
+	}
+	if err != nil {
+		lock.Unlock()
+		return nil, err
+	}
+
+	return j, nil
+}
+
+func (j *fileJournal) load() error {
+
+	fp, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+
+	r := bufio.NewReader(fp)
+	for {
+
+		line, er := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var rec record
+			// 崩溃时可能留下不完整的末行，忽略即可
+			if jsoniter.Unmarshal(line, &rec) == nil {
+				j.s.apply(&rec)
+			}
+		}
+
+		if er == io.EOF {
+			return nil
+		}
+		if er != nil {
+			return er
+		}
+	}
+}
+
+// compact 仅保留待发送与已放弃的消息，原子替换日志文件
+func (j *fileJournal) compact() error {
+
+	list, dead := j.s.p.list(), j.s.dead.list()
+	if err := fs.WriteFileFunc(j.path, 0644, func(w io.Writer) error {
+		if len(list) > 0 {
+			if err := j.write(w, &record{Op: opAppend, Messages: list}); err != nil {
+				return err
+			}
+		}
+		if len(dead) > 0 {
+			return j.write(w, &record{Op: opBury, Messages: dead})
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if j.fp != nil {
+		j.fp.Close()
+		j.fp = nil
+	}
+
+	var err error
+	if j.fp, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	}
+
+	j.stale = 0
+	return nil
+}
+
+func (j *fileJournal) write(w io.Writer, r *record) error {
+	b, err := jsoniter.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func (j *fileJournal) append(r *record) error {
+
+	if j.fp == nil {
+		return fmt.Errorf("outbox: journal 已关闭")
+	}
+
+	if err := j.write(j.fp, r); err != nil {
+		return err
+	}
+	return j.fp.Sync()
+}
+
+func (j *fileJournal) Append(msgs ...*Message) error {
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := j.s.checkAppend(msgs); err != nil {
+		return err
+	}
+	return j.commit(&record{Op: opAppend, Messages: msgs})
+}
+
+func (j *fileJournal) Ack(ids ...string) error {
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	var acked []string
+	for _, id := range ids {
+		if _, ok := j.s.p.data[id]; ok {
+			acked = append(acked, id)
+		}
+	}
+
+	if err := j.commit(&record{Op: opAck, IDs: acked}); err != nil {
+		return err
+	}
+	return j.grow(len(acked))
+}
+
+func (j *fileJournal) Update(msgs ...*Message) error {
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	list := j.s.pendingIDs(msgs)
+	if err := j.commit(&record{Op: opUpdate, Messages: list}); err != nil {
+		return err
+	}
+	return j.grow(len(list))
+}
+
+// grow 累计失效记录，超过阈值时压缩
+func (j *fileJournal) grow(n int) error {
+	if j.stale += n; j.stale >= compactThreshold {
+		return j.compact()
+	}
+	return nil
+}
+
+func (j *fileJournal) Bury(msgs ...*Message) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.commit(&record{Op: opBury, Messages: j.s.pendingIDs(msgs)})
+}
+
+func (j *fileJournal) Revive(ids ...string) ([]*Message, error) {
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	ids = j.s.deadIDs(ids)
+	if err := j.commit(&record{Op: opRevive, IDs: ids}); err != nil {
+		return nil, err
+	}
+	return j.s.revived(ids), nil
+}
+
+// commit 写入日志后再更新内存状态，记录为空时忽略
+func (j *fileJournal) commit(r *record) error {
+
+	if len(r.Messages) < 1 && len(r.IDs) < 1 {
+		return nil
+	}
+
+	if err := j.append(r); err != nil {
+		return err
+	}
+
+	j.s.apply(r)
+	return nil
+}
+
+func (j *fileJournal) Pending() []*Message {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.s.p.list()
+}
+
+func (j *fileJournal) Dead() []*Message {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.s.dead.list()
+}
+
+func (j *fileJournal) Close() error {
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.fp == nil {
+		return nil
+	}
+
+	err := j.fp.Close()
+	j.fp = nil
+	if e := j.lock.Unlock(); err == nil {
+		err = e
+	}
+	return err
+}
+
+type memoryJournal struct {
+	mutex sync.Mutex
+	s     state
+}
+
+// MemoryJournal 内存日志，不持久化，用于测试
+func MemoryJournal() Journal {
+	return &memoryJournal{}
+}
+
+func (j *memoryJournal) Append(msgs ...*Message) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if err := j.s.checkAppend(msgs); err != nil {
+		return err
+	}
+	j.s.apply(&record{Op: opAppend, Messages: msgs})
+	return nil
+}
+
+func (j *memoryJournal) Ack(ids ...string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.s.apply(&record{Op: opAck, IDs: ids})
+	return nil
+}
+
+func (j *memoryJournal) Update(msgs ...*Message) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.s.apply(&record{Op: opUpdate, Messages: j.s.pendingIDs(msgs)})
+	return nil
+}
+
+func (j *memoryJournal) Bury(msgs ...*Message) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.s.apply(&record{Op: opBury, Messages: j.s.pendingIDs(msgs)})
+	return nil
+}
+
+func (j *memoryJournal) Revive(ids ...string) ([]*Message, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	ids = j.s.deadIDs(ids)
+	j.s.apply(&record{Op: opRevive, IDs: ids})
+	return j.s.revived(ids), nil
+}
+
+func (j *memoryJournal) Pending() []*Message {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.s.p.list()
+}
+
+func (j *memoryJournal) Dead() []*Message {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.s.dead.list()
+}
+
+func (j *memoryJournal) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"github.com/google/uuid"
+	"github.com/jack0829/letsgo/callback"
+	"time"
+)
+
+// HeaderDedupKey 写入 Kafka 消息头的去重键，消费方据此去重
+const HeaderDedupKey = "Outbox-ID"
+
+type (
+	Message struct {
+		ID      string            `json:"id"`                // 去重键
+		Topic   string            `json:"topic,omitempty"`   // Producer 已指定 Topic 时留空
+		Key     []byte            `json:"key,omitempty"`     // 分区键
+		Value   []byte            `json:"value,omitempty"`   // 消息体
+		Headers map[string]string `json:"headers,omitempty"` // 消息头
+		Task    *callback.Task    `json:"task,omitempty"`    // 非空时投递 webhook，而不是 Kafka
+		Status  status            `json:"status"`
+	}
+	MessageOption func(m *Message)
+)
+
+type status struct {
+	Tries   int       `json:"tries,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	ReqTime time.Time `json:"req_time"`
+}
+
+// NewMessage 创建 Kafka 消息
+func NewMessage(topic string, value []byte, ops ...MessageOption) *Message {
+
+	m := &Message{
+		Topic: topic,
+		Value: value,
+	}
+
+	for _, op := range ops {
+		op(m)
+	}
+
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return m
+}
+
+// NewCallback 创建 webhook 消息，去重键沿用 Task.ID
+func NewCallback(t *callback.Task) *Message {
+	return &Message{
+		ID:   t.ID,
+		Task: t,
+	}
+}
+
+func WithID(id string) MessageOption {
+	return func(m *Message) {
+		m.ID = id
+	}
+}
+
+func WithKey(key []byte) MessageOption {
+	return func(m *Message) {
+		m.Key = key
+	}
+}
+
+func WithHeader(k, v string) MessageOption {
+	return func(m *Message) {
+		if m.Headers == nil {
+			m.Headers = make(map[string]string)
+		}
+		m.Headers[k] = v
+	}
+}
@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"github.com/jack0829/letsgo/callback"
+	"github.com/jack0829/letsgo/kafka"
+	"time"
+)
+
+type Option func(o *Outbox)
+
+// WithProducer Kafka 消息经 p 投递；Outbox 不关闭 p，由调用方负责
+func WithProducer(p kafka.Producer) Option {
+	return func(o *Outbox) {
+		o.producer = p
+	}
+}
+
+// WithCallback webhook 消息经 cb.Send 同步投递，成功后才确认；重试由 Outbox 负责，cb 无需 Listen
+func WithCallback(cb *callback.Callback) Option {
+	return func(o *Outbox) {
+		o.callback = cb
+	}
+}
+
+func WithRetry(
+	max int, // <=0：不重试；1：重试1次；2：重试2次；...
+	delay time.Duration, // 重试间隔
+) Option {
+	return func(o *Outbox) {
+		o.retryMax = max
+		if delay > 0 {
+			o.retryDelay = delay
+		}
+	}
+}
+
+// WithErrorHandler 日志写入失败、已写入日志的消息进入发送队列失败，或重试耗尽（errors.Is(err, ErrExhausted)）时回调
+func WithErrorHandler(fn func(m *Message, err error)) Option {
+	return func(o *Outbox) {
+		o.onError = fn
+	}
+}
@@ -0,0 +1,190 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/callback"
+	"github.com/jack0829/letsgo/kafka"
+	"github.com/jack0829/letsgo/queue"
+	KAFKA "github.com/segmentio/kafka-go"
+	"time"
+)
+
+// ErrExhausted 重试次数已用完，消息移入已放弃，见 Dead、Redeliver
+var ErrExhausted = errors.New("outbox: 重试次数已用完")
+
+// Outbox 发件箱：消息先写入本地日志，再由 Relay 投递到 Kafka 或 webhook
+type Outbox struct {
+	ctx        context.Context
+	journal    Journal
+	producer   kafka.Producer
+	callback   *callback.Callback
+	queue      *queue.Queue[*Message]
+	retryMax   int
+	retryDelay time.Duration
+	onError    func(m *Message, err error)
+}
+
+func New(
+	ctx context.Context,
+	j Journal,
+	ops ...Option,
+) *Outbox {
+
+	o := &Outbox{
+		ctx:        ctx,
+		journal:    j,
+		retryDelay: time.Second * 5,
+	}
+
+	for _, op := range ops {
+		op(o)
+	}
+
+	// 上次未发送完的消息
+	o.queue = queue.New[*Message](ctx, queue.WithData(j.Pending()...))
+	return o
+}
+
+// Emit 写入日志后进入发送队列，不等待 Kafka 或 webhook 可用
+// 同一批消息要么全部写入，要么全部失败；ID 已在日志中（待发送或已放弃）时返回 ErrDuplicate
+// 写入日志即视为成功：之后进入发送队列失败只交给 WithErrorHandler，消息在下次启动时随 Pending 重新投递
+func (o *Outbox) Emit(msgs ...*Message) error {
+
+	for _, m := range msgs {
+		if err := o.check(m); err != nil {
+			return err
+		}
+	}
+
+	if err := o.journal.Append(msgs...); err != nil {
+		return err
+	}
+
+	for _, m := range msgs {
+		if err := o.queue.Write(m); err != nil {
+			o.error(m, err)
+		}
+	}
+
+	return nil
+}
+
+func (o *Outbox) check(m *Message) error {
+
+	if m == nil || m.ID == "" {
+		return fmt.Errorf("outbox: 消息 ID 不能为空")
+	}
+
+	if m.Task != nil {
+		if o.callback == nil {
+			return fmt.Errorf("outbox: 未设置 callback")
+		}
+		return m.Task.ReadBody()
+	}
+
+	if o.producer == nil {
+		return fmt.Errorf("outbox: 未设置 producer")
+	}
+
+	return nil
+}
+
+// Relay 阻塞投递消息，直到 ctx 结束
+func (o *Outbox) Relay(ctx context.Context) {
+
+	for m := range o.queue.Read(ctx, time.Second) {
+
+		if m == nil {
+			continue
+		}
+
+		err := o.publish(ctx, m)
+		if err == nil {
+			if err = o.journal.Ack(m.ID); err != nil {
+				o.error(m, err)
+			}
+			continue
+		}
+
+		// 日志持有 m，只在副本上修改状态，交由日志保存
+		n := *m
+		n.Status.ReqTime = time.Now()
+		n.Status.Tries++
+		n.Status.Error = err.Error()
+
+		if n.Status.Tries > o.retryMax {
+			// 移入已放弃，不再投递，需要时调用 Redeliver
+			if e := o.journal.Bury(&n); e != nil {
+				o.error(&n, e)
+			}
+			o.error(&n, fmt.Errorf("%w: %v", ErrExhausted, err))
+			continue
+		}
+
+		// 重试次数写入日志，重启后继续累计
+		if e := o.journal.Update(&n); e != nil {
+			o.error(&n, e)
+		}
+
+		time.AfterFunc(o.retryDelay, func() {
+			o.queue.Write(&n)
+		})
+	}
+}
+
+func (o *Outbox) publish(ctx context.Context, m *Message) error {
+
+	// 同步发送，成功后才 Ack
+	if m.Task != nil {
+		return o.callback.Send(ctx, m.Task)
+	}
+
+	msg := KAFKA.Message{
+		Topic: m.Topic,
+		Key:   m.Key,
+		Value: m.Value,
+		Headers: []KAFKA.Header{
+			{Key: HeaderDedupKey, Value: []byte(m.ID)},
+		},
+	}
+	for k, v := range m.Headers {
+		msg.Headers = append(msg.Headers, KAFKA.Header{Key: k, Value: []byte(v)})
+	}
+
+	return o.producer.WriteMessages(ctx, msg)
+}
+
+func (o *Outbox) error(m *Message, err error) {
+	if o.onError != nil {
+		o.onError(m, err)
+	}
+}
+
+// Pending 尚未投递成功的消息
+func (o *Outbox) Pending() []*Message {
+	return o.journal.Pending()
+}
+
+// Dead 重试耗尽后放弃的消息
+func (o *Outbox) Dead() []*Message {
+	return o.journal.Dead()
+}
+
+// Redeliver 将已放弃的消息重新放入发送队列，重试次数清零
+// 与 Emit 相同，日志移回待发送即视为成功，进入发送队列失败只交给 WithErrorHandler
+func (o *Outbox) Redeliver(ids ...string) error {
+
+	list, err := o.journal.Revive(ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if err = o.queue.Write(m); err != nil {
+			o.error(m, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/callback"
+	KAFKA "github.com/segmentio/kafka-go"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testProducer struct {
+	mutex sync.Mutex
+	fails int
+	msgs  []KAFKA.Message
+}
+
+func (p *testProducer) WriteMessages(_ context.Context, msgs ...KAFKA.Message) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.fails > 0 {
+		p.fails--
+		return fmt.Errorf("broker unavailable")
+	}
+	p.msgs = append(p.msgs, msgs...)
+	return nil
+}
+
+func (p *testProducer) count() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.msgs)
+}
+
+func TestFileJournal(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "outbox.log")
+
+	j, err := FileJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewMessage("demo", []byte("a"))
+	b := NewMessage("demo", []byte("b"))
+	c := NewMessage("demo", []byte("c"))
+	if err = j.Append(a, b, a); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("同一批重复应返回 ErrDuplicate: %v", err)
+	}
+	if err = j.Append(a, b, c); err != nil {
+		t.Fatal(err)
+	}
+	if err = j.Append(b); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("待发送的 ID 应返回 ErrDuplicate: %v", err)
+	}
+	if err = j.Ack(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	c.Status.Tries = 3
+	if err = j.Bury(c); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	if j, err = FileJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	list := j.Pending()
+	if len(list) != 1 || list[0].ID != b.ID || string(list[0].Value) != "b" {
+		t.Fatalf("pending: %+v", list)
+	}
+
+	dead := j.Dead()
+	if len(dead) != 1 || dead[0].ID != c.ID || dead[0].Status.Tries != 3 {
+		t.Fatalf("dead: %+v", dead)
+	}
+	if err = j.Append(c); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("已放弃的 ID 应返回 ErrDuplicate: %v", err)
+	}
+
+	revived, err := j.Revive(c.ID, "unknown")
+	if err != nil || len(revived) != 1 || revived[0].Status.Tries != 0 {
+		t.Fatalf("revive: %v %+v", err, revived)
+	}
+	if len(j.Pending()) != 2 || len(j.Dead()) != 0 {
+		t.Fatalf("pending %d, dead %d", len(j.Pending()), len(j.Dead()))
+	}
+}
+
+// TestJournalRequeue 放弃后移回、确认后再次写入，Pending 中只出现一次
+func TestJournalRequeue(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	journals := map[string]func() (Journal, error){
+		"memory": func() (Journal, error) { return MemoryJournal(), nil },
+		"file":   func() (Journal, error) { return FileJournal(path) },
+	}
+
+	for name, open := range journals {
+		t.Run(name, func(t *testing.T) {
+
+			j, err := open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer j.Close()
+
+			a := NewMessage("demo", []byte("a"), WithID("a"))
+			b := NewMessage("demo", []byte("b"), WithID("b"))
+			if err = j.Append(a, b); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = j.Bury(a); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = j.Revive("a"); err != nil {
+				t.Fatal(err)
+			}
+			if list := j.Pending(); len(list) != 2 || list[0].ID != "b" || list[1].ID != "a" {
+				t.Fatalf("revive: %+v", list)
+			}
+
+			if err = j.Ack("b"); err != nil {
+				t.Fatal(err)
+			}
+			if err = j.Append(NewMessage("demo", []byte("b"), WithID("b"))); err != nil {
+				t.Fatal(err)
+			}
+			if list := j.Pending(); len(list) != 2 || list[0].ID != "a" || list[1].ID != "b" {
+				t.Fatalf("re-append: %+v", list)
+			}
+		})
+	}
+}
+
+func TestOutbox(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	j, err := FileJournal(filepath.Join(t.TempDir(), "outbox.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	p := &testProducer{fails: 1}
+	o := New(ctx, j, WithProducer(p), WithRetry(3, time.Millisecond*100))
+	go o.Relay(ctx)
+
+	for i := 0; i < 3; i++ {
+		if err = o.Emit(NewMessage("demo", []byte(fmt.Sprint(i)), WithKey([]byte("k")))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for p.count() < 3 {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("published %d of 3", p.count())
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+
+	for _, m := range p.msgs {
+		if len(m.Headers) < 1 || m.Headers[0].Key != HeaderDedupKey || len(m.Headers[0].Value) == 0 {
+			t.Errorf("missing dedup key: %+v", m.Headers)
+		}
+	}
+
+	if l := len(o.Pending()); l != 0 {
+		t.Errorf("pending %d", l)
+	}
+}
+
+// TestOutboxRetryRestart 重试次数写入日志，重启后继续累计
+func TestOutboxRetryRestart(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	j, err := FileJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第一次运行：失败一次后退出
+	first, stop := context.WithCancel(ctx)
+	o := New(first, j, WithProducer(&testProducer{fails: 100}), WithRetry(1, time.Hour))
+	go o.Relay(first)
+
+	if err = o.Emit(NewMessage("demo", []byte("a"), WithID("a"))); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, ctx, "重试", func() bool {
+		list := o.Pending()
+		return len(list) == 1 && list[0].Status.Tries == 1
+	})
+	stop()
+	j.Close()
+
+	// 重启后再失败一次即超过 WithRetry(1)
+	if j, err = FileJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if list := j.Pending(); len(list) != 1 || list[0].Status.Tries != 1 {
+		t.Fatalf("pending after restart: %+v", list)
+	}
+
+	exhausted := make(chan *Message, 1)
+	o = New(ctx, j,
+		WithProducer(&testProducer{fails: 100}),
+		WithRetry(1, time.Hour),
+		WithErrorHandler(func(m *Message, err error) {
+			if errors.Is(err, ErrExhausted) {
+				exhausted <- m
+			}
+		}),
+	)
+	go o.Relay(ctx)
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("未收到 ErrExhausted")
+	case m := <-exhausted:
+		if m.Status.Tries != 2 {
+			t.Fatalf("tries %d", m.Status.Tries)
+		}
+	}
+	if len(o.Pending()) != 0 || len(o.Dead()) != 1 {
+		t.Fatalf("pending %d, dead %d", len(o.Pending()), len(o.Dead()))
+	}
+}
+
+// testEndpoint webhook 接收端，fail 为 true 时返回 500
+type testEndpoint struct {
+	mutex  sync.Mutex
+	fail   bool
+	bodies []string
+}
+
+func (e *testEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	b, _ := io.ReadAll(r.Body)
+	e.bodies = append(e.bodies, string(b))
+}
+
+func (e *testEndpoint) set(fail bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.fail = fail
+}
+
+func (e *testEndpoint) received() []string {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return append([]string{}, e.bodies...)
+}
+
+func waitFor(t *testing.T, ctx context.Context, what string, ok func() bool) {
+	for !ok() {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("等待超时：%s", what)
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+}
+
+// TestOutboxCallbackCrash Emit 后、投递前进程退出，重启后继续投递
+func TestOutboxCallbackCrash(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	endpoint := &testEndpoint{}
+	srv := httptest.NewServer(endpoint)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "outbox.log")
+	cb := callback.New(ctx)
+
+	// 第一次运行：只 Emit，未 Relay 就退出
+	j, err := FileJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := New(ctx, j, WithCallback(cb))
+	if err = o.Emit(NewCallback(callback.NewTask(srv.URL, callback.WithBodyString(`{"order":1}`)))); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	if n := len(endpoint.received()); n != 0 {
+		t.Fatalf("Relay 前不应投递，received %d", n)
+	}
+
+	// 重启
+	if j, err = FileJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	o = New(ctx, j, WithCallback(cb))
+	go o.Relay(ctx)
+
+	waitFor(t, ctx, "投递", func() bool { return len(o.Pending()) == 0 })
+	if got := endpoint.received(); len(got) != 1 || got[0] != `{"order":1}` {
+		t.Fatalf("received %v", got)
+	}
+}
+
+// TestOutboxCallbackFail 接收端一直失败时不确认，重试耗尽后放弃，恢复后可重新投递
+func TestOutboxCallbackFail(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	endpoint := &testEndpoint{fail: true}
+	srv := httptest.NewServer(endpoint)
+	defer srv.Close()
+
+	exhausted := make(chan *Message, 1)
+	o := New(ctx, MemoryJournal(),
+		WithCallback(callback.New(ctx)),
+		WithRetry(2, time.Millisecond*10),
+		WithErrorHandler(func(m *Message, err error) {
+			if errors.Is(err, ErrExhausted) {
+				exhausted <- m
+			}
+		}),
+	)
+	go o.Relay(ctx)
+
+	m := NewCallback(callback.NewTask(srv.URL, callback.WithBodyString(`{"order":2}`)))
+	if err := o.Emit(m); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Emit(NewCallback(callback.NewTask(srv.URL, callback.WithID(m.ID)))); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("重复 Emit 应返回 ErrDuplicate: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("未收到 ErrExhausted")
+	case got := <-exhausted:
+		if got.ID != m.ID || got.Status.Tries != 3 {
+			t.Fatalf("exhausted %+v", got.Status)
+		}
+	}
+
+	if len(o.Pending()) != 0 || len(o.Dead()) != 1 || len(endpoint.received()) != 0 {
+		t.Fatalf("pending %d, dead %d", len(o.Pending()), len(o.Dead()))
+	}
+
+	endpoint.set(false)
+	if err := o.Redeliver(m.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, ctx, "重新投递", func() bool { return len(endpoint.received()) == 1 })
+	waitFor(t, ctx, "确认", func() bool { return len(o.Pending()) == 0 && len(o.Dead()) == 0 })
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"github.com/jack0829/letsgo/common/limiter"
+	"time"
+)
+
+type Option func(r *rateLimit)
+
+// WithRoute 按路由模板单独设置限速参数，如 "/login"、"/user/:id"
+func WithRoute(route string, rate Rate) Option {
+	return func(r *rateLimit) {
+		r.routes[route] = rate
+	}
+}
+
+// WithLimiter 自定义限速器，例如按 key 使用 limiter.Redis 的 Tenant 实现多副本共享配额
+// Retry-After 为预约后归还得到的实际等待时长
+func WithLimiter(fn func(key string, rate Rate) limiter.RateLimiter) Option {
+	return func(r *rateLimit) {
+		if fn != nil {
+			r.newLimiter = fn
+		}
+	}
+}
+
+// WithIdle 限速器闲置超过 d 后清理，默认 10 分钟
+func WithIdle(d time.Duration) Option {
+	return func(r *rateLimit) {
+		if d > 0 {
+			r.idle = d
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jack0829/letsgo/common/limiter"
+	"github.com/jack0829/letsgo/common/sets"
+	"github.com/jack0829/letsgo/restful"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errorTooManyRequests = "请求过于频繁，请稍后再试"
+)
+
+// KeyFunc 限速维度，返回空字符串时不限速
+type KeyFunc func(g *gin.Context) string
+
+// ByIP 按客户端 IP
+func ByIP(g *gin.Context) string {
+	return g.ClientIP()
+}
+
+// ByUID 按用户，需在 restful.Auth 之后
+func ByUID(g *gin.Context) string {
+	if uid := g.GetInt("uid"); uid > 0 {
+		return strconv.Itoa(uid)
+	}
+	return ""
+}
+
+// ByEID 按企业，需在 restful.Auth 之后
+func ByEID(g *gin.Context) string {
+	if eid := g.GetInt("eid"); eid > 0 {
+		return strconv.Itoa(eid)
+	}
+	return ""
+}
+
+// ByGUID 按访客 Cookie，需在 restful.GUID 之后
+func ByGUID(g *gin.Context) string {
+	return g.GetString(restful.GUIDName)
+}
+
+// ByRoute 按路由模板，如 GET /user/:id
+func ByRoute(g *gin.Context) string {
+	return g.Request.Method + " " + g.FullPath()
+}
+
+// Keys 组合多个维度，任一维度为空时不限速
+func Keys(fns ...KeyFunc) KeyFunc {
+	return func(g *gin.Context) string {
+		keys := make([]string, 0, len(fns))
+		for _, fn := range fns {
+			k := fn(g)
+			if k == "" {
+				return ""
+			}
+			keys = append(keys, k)
+		}
+		return strings.Join(keys, "|")
+	}
+}
+
+// Rate 限速参数，含义同 limiter.New
+type Rate struct {
+	Duration    time.Duration
+	AssignCount int
+	Concurrency int
+}
+
+// retryAfter Retry-After 的秒数，至少 1 秒
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(d.Seconds()))))
+}
+
+type entry struct {
+	l    limiter.RateLimiter
+	used time.Time
+}
+
+type rateLimit struct {
+	key        KeyFunc
+	rate       Rate
+	routes     map[string]Rate // key: 路由模板 g.FullPath()
+	newLimiter func(key string, rate Rate) limiter.RateLimiter
+	idle       time.Duration
+	limiters   sets.Set[string, *entry]
+	mutex      sync.Mutex
+	sweepAt    time.Time
+}
+
+// New 限速中间件，超限时返回 429 及 Retry-After
+func New(key KeyFunc, rate Rate, ops ...Option) gin.HandlerFunc {
+
+	r := &rateLimit{
+		key:    key,
+		rate:   rate,
+		routes: make(map[string]Rate),
+		newLimiter: func(_ string, rate Rate) limiter.RateLimiter {
+			return limiter.New(rate.Duration, rate.AssignCount, rate.Concurrency)
+		},
+		idle: time.Minute * 10,
+	}
+
+	for _, op := range ops {
+		op(r)
+	}
+
+	return r.ginHandler
+}
+
+func (r *rateLimit) ginHandler(g *gin.Context) {
+
+	key := r.key(g)
+	if key == "" {
+		g.Next()
+		return
+	}
+
+	rate := r.rate
+	route := g.FullPath()
+	if v, ok := r.routes[route]; ok {
+		rate = v
+		key = route + "|" + key // 单独计数
+	}
+
+	if ok, wait := r.allow(r.limiter(key, rate), rate); !ok {
+		g.Header("Retry-After", retryAfter(wait))
+		g.AbortWithStatusJSON(
+			http.StatusTooManyRequests,
+			restful.Error(http.StatusTooManyRequests, errorTooManyRequests),
+		)
+		return
+	}
+
+	g.Next()
+}
+
+// allow 取一个令牌，失败时归还预约并返回距下一个令牌的等待时长
+func (r *rateLimit) allow(l limiter.RateLimiter, rate Rate) (bool, time.Duration) {
+
+	d, err := l.Reserve(1)
+	if err != nil {
+		return false, rate.Duration
+	}
+	if d > 0 {
+		l.Cancel(1)
+		return false, d
+	}
+	return true, 0
+}
+
+func (r *rateLimit) limiter(key string, rate Rate) limiter.RateLimiter {
+
+	now := time.Now()
+	r.sweep(now)
+
+	e, _ := r.limiters.SetFn(key, func(_ string, e *entry, exist bool) (*entry, sets.Operator) {
+		if !exist {
+			e = &entry{
+				l: r.newLimiter(key, rate),
+			}
+		}
+		e.used = now
+		return e, sets.Overwrite
+	})
+
+	return e.l
+}
+
+// sweep 清理长时间未使用的限速器
+func (r *rateLimit) sweep(now time.Time) {
+
+	r.mutex.Lock()
+	if now.Sub(r.sweepAt) < r.idle {
+		r.mutex.Unlock()
+		return
+	}
+	r.sweepAt = now
+	r.mutex.Unlock()
+
+	r.limiters.Each(func(k string, e *entry) {
+		r.limiters.SetFn(k, func(_ string, e *entry, exist bool) (*entry, sets.Operator) {
+			if exist && now.Sub(e.used) > r.idle {
+				return nil, sets.Delete
+			}
+			return e, sets.NoAction
+		})
+	})
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jack0829/letsgo/common/limiter"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testServer(h gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(h)
+	ok := func(g *gin.Context) { g.Status(http.StatusOK) }
+	e.GET("/a", ok)
+	e.GET("/login", ok)
+	return e
+}
+
+func testRequest(e *gin.Engine, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("X-Key", key)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func byHeader(g *gin.Context) string {
+	return g.GetHeader("X-Key")
+}
+
+func TestRateLimit(t *testing.T) {
+
+	e := testServer(New(byHeader, Rate{Duration: time.Second * 3, AssignCount: 1, Concurrency: 2},
+		WithRoute("/login", Rate{Duration: time.Minute, AssignCount: 1, Concurrency: 1}),
+	))
+
+	// 开始时颁发 AssignCount 个令牌，各 key 互不影响
+	for _, key := range []string{"a", "b"} {
+		if w := testRequest(e, "/a", key); w.Code != http.StatusOK {
+			t.Fatalf("%s: %d", key, w.Code)
+		}
+	}
+
+	w := testRequest(e, "/a", "a")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expect 429, got %d", w.Code)
+	}
+	if v := w.Header().Get("Retry-After"); v != "3" {
+		t.Errorf("Retry-After %q", v)
+	}
+
+	// 被拒绝的请求不占用令牌，等待时长不会增加
+	if v := testRequest(e, "/a", "a").Header().Get("Retry-After"); v != "3" {
+		t.Errorf("Retry-After after rejection %q", v)
+	}
+
+	// 单独设置的路由单独计数
+	if w = testRequest(e, "/login", "a"); w.Code != http.StatusOK {
+		t.Fatalf("login: %d", w.Code)
+	}
+	if w = testRequest(e, "/login", "a"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("login: %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// key 为空时不限速
+	for i := 0; i < 5; i++ {
+		if w = testRequest(e, "/a", ""); w.Code != http.StatusOK {
+			t.Fatalf("empty key: %d", w.Code)
+		}
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+
+	e := testServer(New(byHeader, Rate{Duration: time.Second * 10, AssignCount: 1, Concurrency: 1}))
+	testRequest(e, "/a", "a")
+
+	// Retry-After 为距下一个令牌的实际时长，而非整个颁发周期
+	time.Sleep(time.Millisecond * 1100)
+	w := testRequest(e, "/a", "a")
+	if v, _ := strconv.Atoi(w.Header().Get("Retry-After")); w.Code != http.StatusTooManyRequests || v != 9 {
+		t.Fatalf("%d Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimitCustom(t *testing.T) {
+
+	var (
+		mutex   sync.Mutex
+		created = make(map[string]int)
+	)
+
+	h := New(
+		Keys(byHeader, ByRoute),
+		Rate{Duration: time.Second * 5, AssignCount: 1, Concurrency: 1},
+		WithIdle(time.Millisecond*50),
+		WithLimiter(func(key string, rate Rate) limiter.RateLimiter {
+			mutex.Lock()
+			created[key]++
+			mutex.Unlock()
+			return limiter.New(rate.Duration, rate.AssignCount, rate.Concurrency)
+		}),
+	)
+	e := testServer(h)
+
+	testRequest(e, "/a", "a")
+	w := testRequest(e, "/a", "a")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "5" {
+		t.Fatalf("%d Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if created["a|GET /a"] != 1 {
+		t.Fatalf("created %v", created)
+	}
+
+	// 闲置超时后清理，再次请求时重新创建
+	time.Sleep(time.Millisecond * 60)
+	testRequest(e, "/a", "b")
+	if w = testRequest(e, "/a", "a"); w.Code != http.StatusOK {
+		t.Fatalf("after sweep: %d", w.Code)
+	}
+	if created["a|GET /a"] != 2 || created["b|GET /a"] != 1 {
+		t.Fatalf("created %v", created)
+	}
+}
@@ -2,6 +2,7 @@ package rbac
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/jack0829/letsgo/common/sets"
 	"github.com/jack0829/letsgo/restful"
 	"net/http"
 )
@@ -15,7 +16,7 @@ const (
 	RoleEnterpriseAdmin = 1001 // 企业管理员
 )
 
-func basic(ctx *gin.Context, roles []int) (role map[int]struct{}, finish bool) {
+func basic(ctx *gin.Context, roles []int) (role sets.HashSet[int], finish bool) {
 
 	if len(roles) == 0 {
 		finish = true
@@ -35,10 +36,7 @@ func basic(ctx *gin.Context, roles []int) (role map[int]struct{}, finish bool) {
 		return
 	}
 
-	role = make(map[int]struct{})
-	for _, r := range userRoles {
-		role[r] = struct{}{}
-	}
+	role = sets.NewHashSet(userRoles...)
 	return
 }
 
@@ -51,11 +49,9 @@ func Any(roles ...int) gin.HandlerFunc {
 			return
 		}
 
-		for _, r := range roles {
-			if _, ok := role[r]; ok {
-				ctx.Next()
-				return
-			}
+		if role.HasAny(roles...) {
+			ctx.Next()
+			return
 		}
 
 		ctx.AbortWithStatusJSON(
@@ -74,14 +70,12 @@ func All(roles ...int) gin.HandlerFunc {
 			return
 		}
 
-		for _, r := range roles {
-			if _, ok := role[r]; !ok {
-				ctx.AbortWithStatusJSON(
-					http.StatusNotAcceptable,
-					restful.Error(http.StatusNotAcceptable, errorNotAcceptable),
-				)
-				return
-			}
+		if !role.HasAll(roles...) {
+			ctx.AbortWithStatusJSON(
+				http.StatusNotAcceptable,
+				restful.Error(http.StatusNotAcceptable, errorNotAcceptable),
+			)
+			return
 		}
 
 		ctx.Next()
@@ -97,14 +91,12 @@ func Not(roles ...int) gin.HandlerFunc {
 			return
 		}
 
-		for _, r := range roles {
-			if _, ok := role[r]; ok {
-				ctx.AbortWithStatusJSON(
-					http.StatusNotAcceptable,
-					restful.Error(http.StatusNotAcceptable, errorNotAcceptable),
-				)
-				return
-			}
+		if role.HasAny(roles...) {
+			ctx.AbortWithStatusJSON(
+				http.StatusNotAcceptable,
+				restful.Error(http.StatusNotAcceptable, errorNotAcceptable),
+			)
+			return
 		}
 
 		ctx.Next()
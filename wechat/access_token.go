@@ -21,27 +21,31 @@ func (tk *AccessToken) Expired() bool {
 	return time.Until(tk.ExpireAt) < time.Minute // 留1分钟冗余无缝更换
 }
 
-// GetAccessToken 获取应用 AccessToken
-func (w *Wechat) GetAccessToken() (tk *AccessToken, err error) {
+// GetAccessToken 获取应用 AccessToken，并发刷新时只请求一次
+func (w *Wechat) GetAccessToken() (*AccessToken, error) {
 
-	if s := w.storage.accessToken; s != nil {
-
-		if tk = s.GetAccessToken(w.AppID); tk != nil && !tk.Expired() {
-			return
+	s := w.storage.accessToken
+	if s != nil {
+		if tk := s.GetAccessToken(w.AppID); tk != nil && !tk.Expired() {
+			return tk, nil
 		}
-
-		defer func() {
-			if err == nil {
-				err = s.SetAccessToken(tk)
-			}
-		}()
 	}
 
-	if w.ops.stableAccessToken {
-		return w.getStableAccessToken()
-	}
+	tk, err, _ := w.flight.Do(w.AppID, func() (tk *AccessToken, err error) {
+
+		if w.ops.stableAccessToken {
+			tk, err = w.getStableAccessToken()
+		} else {
+			tk, err = w.getAccessToken()
+		}
+
+		if err == nil && s != nil {
+			err = s.SetAccessToken(tk)
+		}
+		return
+	})
 
-	return w.getAccessToken()
+	return tk, err
 }
 
 func (w *Wechat) getAccessToken() (*AccessToken, error) {
@@ -82,7 +82,7 @@ func (w *Wechat) RefreshOAuthAccessToken(tk *OAuthAccessToken) (*OAuthAccessToke
 	}
 
 	tk.AccessToken = r.AccessToken
-	tk.ExpireAt = time.Now().Add(time.Duration(r.ExpiresIn))
+	tk.ExpireAt = time.Now().Add(time.Second * time.Duration(r.ExpiresIn))
 	tk.RefreshToken = r.RefreshToken
 	// tk.OpenID = r.OpenID
 	tk.Scope = r.Scope
@@ -133,7 +133,7 @@ func (w *Wechat) Code2OAuthAccessToken(code string) (*OAuthAccessToken, error) {
 	tk := &OAuthAccessToken{
 		AppID:          w.AppID,
 		AccessToken:    r.AccessToken,
-		ExpireAt:       time.Now().Add(time.Duration(r.ExpiresIn)),
+		ExpireAt:       time.Now().Add(time.Second * time.Duration(r.ExpiresIn)),
 		RefreshToken:   r.RefreshToken,
 		OpenID:         r.OpenID,
 		Scope:          r.Scope,
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"github.com/jack0829/letsgo/common/fs"
 	"github.com/jack0829/letsgo/wechat"
+	"io"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
@@ -26,19 +26,9 @@ func File(baseDir string) *file {
 }
 
 func (s *file) write(path string, v any) error {
-
-	dir := filepath.Dir(path)
-	if err := fs.MustDir(dir); err != nil {
-		return err
-	}
-
-	fp, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer fp.Close()
-
-	return json.NewEncoder(fp).Encode(v)
+	return fs.WriteFileFunc(path, 0644, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(v)
+	})
 }
 
 func (s *file) read(path string, v any) error {
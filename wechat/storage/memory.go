@@ -1,11 +1,24 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jack0829/letsgo/common/fs"
 	"github.com/jack0829/letsgo/common/sets"
 	"github.com/jack0829/letsgo/wechat"
 	"gopkg.in/yaml.v3"
 	"io"
+	"log"
+	"path/filepath"
 	"sync"
+	"time"
+)
+
+// 与 Redis 存储保持一致
+const (
+	sessionTTL = time.Hour * 24 * 7
+	oauthTTL   = time.Hour * 24 * 20 // AccessToken 过期后仍需 RefreshToken 刷新，按 20 天保留
 )
 
 var (
@@ -16,25 +29,28 @@ var (
 type memory struct {
 	once             sync.Once
 	initialized      bool
-	accessToken      *sets.Set[string, *wechat.AccessToken]
-	jsApiTicket      *sets.Set[string, *wechat.JsApiTicket]
-	session          *sets.Set[string, *wechat.Session]
-	oauthAccessToken *sets.Set[string, *wechat.OAuthAccessToken]
+	accessToken      *sets.Cache[string, *wechat.AccessToken]
+	jsApiTicket      *sets.Cache[string, *wechat.JsApiTicket]
+	session          *sets.Cache[string, *wechat.Session]
+	oauthAccessToken *sets.Cache[string, *wechat.OAuthAccessToken]
 }
 
 func (s *memory) initialize() {
 	s.once.Do(func() {
-		s.accessToken = &sets.Set[string, *wechat.AccessToken]{}
-		s.jsApiTicket = &sets.Set[string, *wechat.JsApiTicket]{}
-		s.session = &sets.Set[string, *wechat.Session]{}
-		s.oauthAccessToken = &sets.Set[string, *wechat.OAuthAccessToken]{}
+		opts := []sets.CacheOption{
+			sets.WithJanitor(context.Background(), time.Minute),
+		}
+		s.accessToken = sets.NewCache[string, *wechat.AccessToken](opts...)
+		s.jsApiTicket = sets.NewCache[string, *wechat.JsApiTicket](opts...)
+		s.session = sets.NewCache[string, *wechat.Session](opts...)
+		s.oauthAccessToken = sets.NewCache[string, *wechat.OAuthAccessToken](opts...)
 		s.initialized = true
 	})
 }
 
 func (s *memory) SetAccessToken(token *wechat.AccessToken) error {
 	s.initialize()
-	s.accessToken.Set(token.AppID, token)
+	s.accessToken.SetUntil(token.AppID, token, token.ExpireAt)
 	return nil
 }
 
@@ -48,7 +64,7 @@ func (s *memory) GetAccessToken(appID string) *wechat.AccessToken {
 
 func (s *memory) SetJsApiTicket(ticket *wechat.JsApiTicket) error {
 	s.initialize()
-	s.jsApiTicket.Set(ticket.AppID, ticket)
+	s.jsApiTicket.SetUntil(ticket.AppID, ticket, ticket.ExpireAt)
 	return nil
 }
 
@@ -63,7 +79,7 @@ func (s *memory) GetJsApiTicket(appID string) *wechat.JsApiTicket {
 func (s *memory) SetSession(ws *wechat.Session) error {
 	s.initialize()
 	key := ws.AppID + ":" + ws.OpenID
-	s.session.Set(key, ws)
+	s.session.SetTTL(key, ws, sessionTTL)
 	return nil
 }
 
@@ -86,7 +102,7 @@ func (s *memory) DeleteSession(appID, openID string) error {
 func (s *memory) SetOAuthAccessToken(tk *wechat.OAuthAccessToken) error {
 	s.initialize()
 	key := tk.AppID + ":" + tk.OpenID
-	s.oauthAccessToken.Set(key, tk)
+	s.oauthAccessToken.SetTTL(key, tk, oauthTTL)
 	return nil
 }
 
@@ -134,3 +150,55 @@ func DumpMemory(w io.Writer) {
 		defaultMemory.dumpTo(w)
 	}
 }
+
+type snapshotter interface {
+	SaveFile(path string, codec sets.Codec) error
+	LoadFile(path string, codec sets.Codec) (int, error)
+}
+
+func (s *memory) files(dir string) map[string]snapshotter {
+	return map[string]snapshotter{
+		filepath.Join(dir, "access_token.json"):       s.accessToken,
+		filepath.Join(dir, "jsapi_ticket.json"):       s.jsApiTicket,
+		filepath.Join(dir, "session.json"):            s.session,
+		filepath.Join(dir, "oauth_access_token.json"): s.oauthAccessToken,
+	}
+}
+
+// PersistMemory 从 dir 恢复内存存储，之后每 interval（interval <= 0 时只在结束时）及 ctx 结束时写回，重启后 token 与 session 不丢失
+// 返回的 done 在最后一次写回完成后关闭，退出进程前应等待：
+//
+//	done, err := storage.PersistMemory(ctx, dir, time.Minute)
+//	...
+//	cancel()
+//	<-done
+func PersistMemory(ctx context.Context, dir string, interval time.Duration) (done <-chan struct{}, err error) {
+
+	if err = fs.MustDir(dir); err != nil {
+		return nil, err
+	}
+
+	defaultMemory.initialize()
+	files := defaultMemory.files(dir)
+	for path, c := range files {
+		if _, err = c.LoadFile(path, sets.JSON); err != nil {
+			return nil, fmt.Errorf("恢复 %s 失败: %w", path, err)
+		}
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		defer close(ch)
+		sets.AutoSave(ctx, interval, func() error {
+			var errs []error
+			for path, c := range files {
+				errs = append(errs, c.SaveFile(path, sets.JSON))
+			}
+			return errors.Join(errs...)
+		}, func(err error) {
+			log.Printf("wechat: 保存内存存储失败: %s", err)
+		})
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"github.com/jack0829/letsgo/wechat"
+	"testing"
+	"time"
+)
+
+// AccessToken 过期后仍可取到，以便用 RefreshToken 刷新
+func TestMemoryOAuthAccessToken(t *testing.T) {
+
+	s := &memory{}
+	tk := &wechat.OAuthAccessToken{
+		AppID:        "app",
+		OpenID:       "open",
+		AccessToken:  "expired",
+		RefreshToken: "refresh",
+		ExpireAt:     time.Now().Add(-time.Hour),
+	}
+	if err := s.SetOAuthAccessToken(tk); err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.GetOAuthAccessToken("app", "open")
+	if got == nil || got.RefreshToken != "refresh" || !got.Expired() {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+// interval 为 0 时只在 ctx 结束时写回，done 关闭后可从文件恢复
+func TestPersistMemory(t *testing.T) {
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done, err := PersistMemory(ctx, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Memory.SetSession(&wechat.Session{AppID: "app", OpenID: "open"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	<-done
+
+	Memory.DeleteSession("app", "open")
+	ctx, cancel = context.WithCancel(context.Background())
+	if done, err = PersistMemory(ctx, dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cancel()
+		<-done
+	}()
+	if got := Memory.GetSession("app", "open"); got == nil {
+		t.Fatal("session 未恢复")
+	}
+}
@@ -1,6 +1,10 @@
 package wechat
 
-import "net/http"
+import (
+	"github.com/jack0829/letsgo/common/async"
+	"github.com/jack0829/letsgo/http/breaker"
+	"net/http"
+)
 
 type Wechat struct {
 	AppID     string
@@ -8,6 +12,8 @@ type Wechat struct {
 	e         *Event
 	c         *http.Client
 	storage   storage
+	flight    async.Flight[string, *AccessToken] // 合并并发刷新 AccessToken
+	breaker   *breaker.Breaker
 	ops       struct {
 		stableAccessToken bool
 	}
@@ -30,6 +36,12 @@ func New(
 		op(w)
 	}
 
+	if w.breaker != nil {
+		c := *w.c
+		c.Transport = w.breaker.RoundTripper(c.Transport)
+		w.c = &c
+	}
+
 	return w
 }
 
@@ -41,6 +53,13 @@ func WithClient(c *http.Client) Option {
 	}
 }
 
+// WithBreaker 微信接口不可用时熔断，避免持续请求
+func WithBreaker(b *breaker.Breaker) Option {
+	return func(w *Wechat) {
+		w.breaker = b
+	}
+}
+
 func WithStorage(s Storage) Option {
 	return func(w *Wechat) {
 		w.storage.accessToken = s